@@ -6,11 +6,9 @@ require (
 	github.com/BurntSushi/toml v1.5.0
 	github.com/lxn/walk v0.0.0-20210112085537-c389da54e794
 	github.com/lxn/win v0.0.0-20210218163916-a377121e959e
+	golang.org/x/sys v0.36.0
 )
 
-require (
-	golang.org/x/sys v0.36.0 // indirect
-	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
-)
+require gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
 
 ignore ./reference
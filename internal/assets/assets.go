@@ -0,0 +1,31 @@
+// Package assets locates the files goDrawer ships in its assets directory.
+package assets
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// Paths of the bundled assets, relative to the directory holding assets.
+var (
+	BrandFont = filepath.Join("assets", "fonts", "Lobster-Regular.ttf")
+	AppIcon   = filepath.Join("assets", "drawer.icon.4.ico")
+)
+
+// Path returns where the asset rel is found: relative to the working
+// directory as during development, or else next to the executable as in an
+// installed copy. When neither exists the working-directory path is
+// returned so errors name it.
+func Path(rel string) string {
+	if _, err := os.Stat(rel); err == nil {
+		return rel
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		candidate := filepath.Join(filepath.Dir(exe), rel)
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return rel
+}
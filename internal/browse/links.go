@@ -0,0 +1,97 @@
+package browse
+
+import (
+	"io/fs"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// maxLinkHops bounds how many links realLocation follows, so that link
+// cycles end.
+const maxLinkHops = 40
+
+// Locator is implemented by file systems that can place absolute paths,
+// such as the targets of links, in themselves.
+type Locator interface {
+	// Location returns where the absolute path p lies in the file system,
+	// and false when it lies outside.
+	Location(p string) (string, bool)
+}
+
+// realLocation returns location with every symbolic link and junction on
+// the way followed. It returns false when a link cannot be read or leads
+// out of fsys.
+func realLocation(fsys fs.FS, location string) (string, bool) {
+	resolved := "."
+	rest := splitLocation(location)
+	hops := 0
+	for len(rest) > 0 {
+		next := path.Join(resolved, rest[0])
+		rest = rest[1:]
+
+		target, isLink, ok := readLink(fsys, next)
+		if !ok {
+			return "", false
+		}
+		if !isLink {
+			resolved = next
+			continue
+		}
+
+		hops++
+		if hops > maxLinkHops {
+			return "", false
+		}
+		target, ok = linkLocation(fsys, resolved, target)
+		if !ok {
+			return "", false
+		}
+		resolved = "."
+		rest = append(splitLocation(target), rest...)
+	}
+	return resolved, true
+}
+
+// readLink returns the target of the link at location, and whether it is
+// a link at all. Junctions and other reparse points show up as irregular
+// files; those whose target cannot be read are taken for plain entries.
+func readLink(fsys fs.FS, location string) (target string, isLink, ok bool) {
+	info, err := fs.Lstat(fsys, location)
+	if err != nil {
+		// Entries that do not exist lead nowhere; Stat reports them.
+		return "", false, true
+	}
+
+	switch mode := info.Mode(); {
+	case mode&fs.ModeSymlink != 0:
+		target, err := fs.ReadLink(fsys, location)
+		return target, true, err == nil
+	case mode&fs.ModeIrregular != 0:
+		if target, err := fs.ReadLink(fsys, location); err == nil {
+			return target, true, true
+		}
+	}
+	return "", false, true
+}
+
+// linkLocation places the target of a link in the folder dir of fsys.
+func linkLocation(fsys fs.FS, dir, target string) (string, bool) {
+	if filepath.IsAbs(target) || path.IsAbs(target) || filepath.VolumeName(target) != "" {
+		locator, ok := fsys.(Locator)
+		if !ok {
+			return "", false
+		}
+		return locator.Location(target)
+	}
+
+	location := path.Join(dir, filepath.ToSlash(target))
+	return location, fs.ValidPath(location)
+}
+
+func splitLocation(location string) []string {
+	if location == "." || location == "" {
+		return nil
+	}
+	return strings.Split(location, "/")
+}
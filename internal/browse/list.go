@@ -0,0 +1,275 @@
+// Package browse holds the platform-independent part of drawer windows: the
+// entries of a folder, how they are filtered and sorted, and how a window
+// moves between folders.
+package browse
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Item is an entry of a folder.
+type Item struct {
+	Name  string
+	Path  string
+	IsDir bool
+	// Link is set for symbolic links and junctions. IsDir then tells
+	// whether the link leads to a folder.
+	Link    bool
+	Size    int64
+	ModTime time.Time
+	// Err is set when the details of the entry could not be read; only
+	// Name, Path and IsDir are known then.
+	Err error
+}
+
+// EditOp is the kind of an Edit.
+type EditOp int
+
+const (
+	EditInsert EditOp = iota
+	EditRemove
+	EditChange
+)
+
+// Edit is a change of a row of a List, as returned by Update. Index is the
+// row at the time the edit is applied.
+type Edit struct {
+	Op    EditOp
+	Index int
+}
+
+// Column is a column a List can be sorted by.
+type Column int
+
+const (
+	ColumnName Column = iota
+	ColumnSize
+	ColumnModified
+)
+
+// List is the filtered and sorted view of a folder's items.
+type List struct {
+	all        []Item
+	items      []Item
+	filter     string
+	column     Column
+	descending bool
+}
+
+// Len returns the number of items that pass the filter.
+func (l *List) Len() int {
+	return len(l.items)
+}
+
+// At returns the i-th item that passes the filter.
+func (l *List) At(i int) Item {
+	return l.items[i]
+}
+
+// Items returns the items that pass the filter, in order.
+func (l *List) Items() []Item {
+	return l.items
+}
+
+// Reset replaces the items.
+func (l *List) Reset(items []Item) {
+	l.all = items
+	l.refilter()
+}
+
+// Append adds items, keeping the list filtered and sorted.
+func (l *List) Append(items []Item) {
+	l.all = append(l.all, items...)
+
+	pattern := strings.ToLower(l.filter)
+	for _, item := range items {
+		if MatchesFilter(strings.ToLower(item.Name), pattern) {
+			l.items = append(l.items, item)
+		}
+	}
+	sortItems(l.items, l.column, l.descending)
+}
+
+// Update replaces the items like Reset and returns the edits that turn the
+// previous rows into the new ones, in the order they are to be applied:
+// removals from the bottom up, then insertions from the top down, then
+// changes. Items are told apart by name; one that moves is removed and
+// inserted again.
+func (l *List) Update(items []Item) []Edit {
+	old := slices.Clone(l.items)
+	l.Reset(items)
+	return diffItems(old, l.items)
+}
+
+// Filter returns the current filter.
+func (l *List) Filter() string {
+	return l.filter
+}
+
+// SetFilter shows only items whose name contains filter, ignoring case.
+// Filters containing * or ? are matched as glob patterns instead.
+func (l *List) SetFilter(filter string) {
+	l.filter = strings.TrimSpace(filter)
+	l.refilter()
+}
+
+// SortOrder returns the column the items are sorted by and whether the
+// order is descending.
+func (l *List) SortOrder() (Column, bool) {
+	return l.column, l.descending
+}
+
+// Sort orders the items by column. Folders come first when sorting by name
+// or size.
+func (l *List) Sort(column Column, descending bool) {
+	l.column, l.descending = column, descending
+	sortItems(l.items, column, descending)
+}
+
+func (l *List) refilter() {
+	pattern := strings.ToLower(l.filter)
+
+	l.items = l.items[:0]
+	for _, item := range l.all {
+		if MatchesFilter(strings.ToLower(item.Name), pattern) {
+			l.items = append(l.items, item)
+		}
+	}
+
+	sortItems(l.items, l.column, l.descending)
+}
+
+func sortItems(items []Item, column Column, descending bool) {
+	less := func(i, j int) bool {
+		lhs := items[i]
+		rhs := items[j]
+
+		switch column {
+		case ColumnName:
+			if lhs.IsDir != rhs.IsDir {
+				return lhs.IsDir
+			}
+			if descending {
+				return lhs.Name > rhs.Name
+			}
+			return lhs.Name < rhs.Name
+		case ColumnSize:
+			if lhs.IsDir != rhs.IsDir {
+				return lhs.IsDir
+			}
+			if descending {
+				return lhs.Size > rhs.Size
+			}
+			return lhs.Size < rhs.Size
+		case ColumnModified:
+			if descending {
+				return lhs.ModTime.After(rhs.ModTime)
+			}
+			return lhs.ModTime.Before(rhs.ModTime)
+		default:
+			return false
+		}
+	}
+
+	sort.SliceStable(items, less)
+}
+
+func diffItems(old, updated []Item) []Edit {
+	oldRows := make(map[string]int, len(old))
+	for i, item := range old {
+		oldRows[item.Name] = i
+	}
+
+	// The largest set of items found in both lists in the same relative
+	// order stays where it is; the other items move.
+	var common []string
+	var rows []int
+	for _, item := range updated {
+		if i, ok := oldRows[item.Name]; ok {
+			common = append(common, item.Name)
+			rows = append(rows, i)
+		}
+	}
+	kept := make(map[string]bool, len(common))
+	for _, i := range longestIncreasing(rows) {
+		kept[common[i]] = true
+	}
+
+	var edits []Edit
+	for i := len(old) - 1; i >= 0; i-- {
+		if !kept[old[i].Name] {
+			edits = append(edits, Edit{Op: EditRemove, Index: i})
+		}
+	}
+	for i, item := range updated {
+		if !kept[item.Name] {
+			edits = append(edits, Edit{Op: EditInsert, Index: i})
+		}
+	}
+	for i, item := range updated {
+		if kept[item.Name] && !sameItem(old[oldRows[item.Name]], item) {
+			edits = append(edits, Edit{Op: EditChange, Index: i})
+		}
+	}
+	return edits
+}
+
+// longestIncreasing returns the positions of a longest strictly increasing
+// subsequence of values, in order.
+func longestIncreasing(values []int) []int {
+	// tails[k] is the position of the smallest value ending an increasing
+	// subsequence of length k+1; prev links each position to the one
+	// before it in its subsequence.
+	var tails []int
+	prev := make([]int, len(values))
+	for i, v := range values {
+		k := sort.Search(len(tails), func(k int) bool { return values[tails[k]] >= v })
+		if k > 0 {
+			prev[i] = tails[k-1]
+		} else {
+			prev[i] = -1
+		}
+		if k == len(tails) {
+			tails = append(tails, i)
+		} else {
+			tails[k] = i
+		}
+	}
+
+	seq := make([]int, len(tails))
+	if len(tails) == 0 {
+		return seq
+	}
+	i := tails[len(tails)-1]
+	for k := len(seq) - 1; k >= 0; k-- {
+		seq[k] = i
+		i = prev[i]
+	}
+	return seq
+}
+
+func sameItem(a, b Item) bool {
+	return a.Path == b.Path &&
+		a.IsDir == b.IsDir &&
+		a.Link == b.Link &&
+		a.Size == b.Size &&
+		a.ModTime.Equal(b.ModTime) &&
+		(a.Err == nil) == (b.Err == nil)
+}
+
+// MatchesFilter reports whether name passes pattern. Both are expected in
+// lower case.
+func MatchesFilter(name, pattern string) bool {
+	if pattern == "" {
+		return true
+	}
+	if strings.ContainsAny(pattern, "*?") {
+		ok, err := filepath.Match(pattern, name)
+		return err == nil && ok
+	}
+	return strings.Contains(name, pattern)
+}
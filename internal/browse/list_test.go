@@ -0,0 +1,227 @@
+package browse
+
+import (
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"time"
+)
+
+func names(items []Item) []string {
+	var out []string
+	for _, item := range items {
+		out = append(out, item.Name)
+	}
+	return out
+}
+
+func file(name string, size int64, modified int) Item {
+	return Item{Name: name, Path: "/d/" + name, Size: size, ModTime: time.Unix(int64(modified), 0)}
+}
+
+func dir(name string, modified int) Item {
+	return Item{Name: name, Path: "/d/" + name, IsDir: true, ModTime: time.Unix(int64(modified), 0)}
+}
+
+func sample() []Item {
+	return []Item{
+		file("b.txt", 30, 3),
+		dir("src", 5),
+		file("a.go", 10, 4),
+		dir("docs", 1),
+		file("c.md", 20, 2),
+	}
+}
+
+func TestListSort(t *testing.T) {
+	tests := []struct {
+		name       string
+		column     Column
+		descending bool
+		want       []string
+	}{
+		{"name", ColumnName, false, []string{"docs", "src", "a.go", "b.txt", "c.md"}},
+		{"name descending", ColumnName, true, []string{"src", "docs", "c.md", "b.txt", "a.go"}},
+		{"size", ColumnSize, false, []string{"docs", "src", "a.go", "c.md", "b.txt"}},
+		{"size descending", ColumnSize, true, []string{"docs", "src", "b.txt", "c.md", "a.go"}},
+		{"modified", ColumnModified, false, []string{"docs", "c.md", "b.txt", "a.go", "src"}},
+		{"modified descending", ColumnModified, true, []string{"src", "a.go", "b.txt", "c.md", "docs"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l List
+			l.Reset(sample())
+			l.Sort(tt.column, tt.descending)
+			if got := names(l.Items()); !slices.Equal(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if column, descending := l.SortOrder(); column != tt.column || descending != tt.descending {
+				t.Errorf("SortOrder() = %v, %v, want %v, %v", column, descending, tt.column, tt.descending)
+			}
+		})
+	}
+}
+
+func TestListFilter(t *testing.T) {
+	tests := []struct {
+		filter string
+		want   []string
+	}{
+		{"", []string{"docs", "src", "a.go", "b.txt", "c.md"}},
+		{"S", []string{"docs", "src"}},
+		{"  .TXT ", []string{"b.txt"}},
+		{"*.go", []string{"a.go"}},
+		{"?.*", []string{"a.go", "b.txt", "c.md"}},
+		{"[", nil},
+		{"nothing", nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.filter, func(t *testing.T) {
+			var l List
+			l.Reset(sample())
+			l.SetFilter(tt.filter)
+			if got := names(l.Items()); !slices.Equal(got, tt.want) {
+				t.Errorf("SetFilter(%q) shows %v, want %v", tt.filter, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestListFilterKeepsSortOrder(t *testing.T) {
+	var l List
+	l.Reset(sample())
+	l.Sort(ColumnSize, true)
+	l.SetFilter("*.*")
+	l.SetFilter("")
+
+	want := []string{"src", "docs", "b.txt", "c.md", "a.go"}
+	if got := names(l.Items()); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestListAppend(t *testing.T) {
+	var l List
+	l.SetFilter("a")
+	l.Reset(sample()[:2])
+	l.Append([]Item{file("aa.txt", 1, 1), dir("assets", 1), file("zz.txt", 1, 1)})
+
+	want := []string{"assets", "aa.txt"}
+	if got := names(l.Items()); !slices.Equal(got, want) {
+		t.Errorf("after Append got %v, want %v", got, want)
+	}
+
+	// Items hidden by the filter are kept and show once it is cleared.
+	l.SetFilter("")
+	want = []string{"assets", "src", "aa.txt", "b.txt", "zz.txt"}
+	if got := names(l.Items()); !slices.Equal(got, want) {
+		t.Errorf("after clearing the filter got %v, want %v", got, want)
+	}
+}
+
+func TestListUpdate(t *testing.T) {
+	changed := file("b.txt", 31, 3)
+
+	tests := []struct {
+		name    string
+		old     []Item
+		updated []Item
+		want    []Edit
+	}{
+		{
+			name:    "unchanged",
+			old:     sample(),
+			updated: sample(),
+		},
+		{
+			name:    "insert",
+			old:     sample(),
+			updated: append(sample(), file("b2.txt", 1, 1)),
+			want:    []Edit{{EditInsert, 4}},
+		},
+		{
+			name:    "remove",
+			old:     sample(),
+			updated: slices.Delete(sample(), 0, 1),
+			want:    []Edit{{EditRemove, 3}},
+		},
+		{
+			name:    "change",
+			old:     sample(),
+			updated: []Item{changed, dir("src", 5), file("a.go", 10, 4), dir("docs", 1), file("c.md", 20, 2)},
+			want:    []Edit{{EditChange, 3}},
+		},
+		{
+			name:    "empty",
+			old:     sample(),
+			updated: nil,
+			want:    []Edit{{EditRemove, 4}, {EditRemove, 3}, {EditRemove, 2}, {EditRemove, 1}, {EditRemove, 0}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var l List
+			l.Reset(tt.old)
+			if got := l.Update(tt.updated); !slices.Equal(got, tt.want) {
+				t.Errorf("Update() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// A file that moves to the top when sorting by date is one removal and one
+// insertion, however long the list.
+func TestListUpdateMoveToTop(t *testing.T) {
+	var items []Item
+	for i := range 50 {
+		items = append(items, file(string(rune('A'+i)), 1, 100-i))
+	}
+
+	var l List
+	l.Sort(ColumnModified, true)
+	l.Reset(items)
+
+	touched := slices.Clone(items)
+	touched[30].ModTime = time.Unix(1000, 0)
+	edits := l.Update(touched)
+
+	want := []Edit{{EditRemove, 30}, {EditInsert, 0}}
+	if !slices.Equal(edits, want) {
+		t.Errorf("Update() = %v, want %v", edits, want)
+	}
+}
+
+func TestDiffItemsApplies(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	random := func() []Item {
+		var items []Item
+		for _, i := range r.Perm(12)[:r.IntN(12)] {
+			items = append(items, file(string(rune('a'+i)), int64(r.IntN(2)), 0))
+		}
+		return items
+	}
+
+	for range 500 {
+		old, updated := random(), random()
+		edits := diffItems(old, updated)
+
+		rows := slices.Clone(old)
+		for _, edit := range edits {
+			switch edit.Op {
+			case EditRemove:
+				rows = slices.Delete(rows, edit.Index, edit.Index+1)
+			case EditInsert:
+				rows = slices.Insert(rows, edit.Index, updated[edit.Index])
+			case EditChange:
+				rows[edit.Index] = updated[edit.Index]
+			}
+		}
+
+		if !slices.EqualFunc(rows, updated, sameItem) || !slices.Equal(names(rows), names(updated)) {
+			t.Fatalf("applying %v to %v gives %v, want %v", edits, names(old), names(rows), names(updated))
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package browse
+
+import (
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"path"
+)
+
+// DefaultBatchSize is how many entries Stream reads at a time.
+const DefaultBatchSize = 256
+
+// Stream lists the folder dir of fsys, calling emit with every batch of
+// up to size items as soon as it is read, until the folder is read or ctx
+// is canceled. Item paths are in fsys. Entries whose details cannot be
+// read are reported as items with Err set. When ctx is canceled Stream
+// returns its error.
+func Stream(ctx context.Context, fsys fs.FS, dir string, size int, emit func([]Item)) error {
+	if size <= 0 {
+		size = DefaultBatchSize
+	}
+
+	file, err := fsys.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	reader, ok := file.(fs.ReadDirFile)
+	if !ok {
+		return &fs.PathError{Op: "readdir", Path: dir, Err: errors.New("not implemented")}
+	}
+
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		entries, err := reader.ReadDir(size)
+		if len(entries) > 0 {
+			items, cerr := readItems(ctx, fsys, dir, entries)
+			if len(items) > 0 {
+				emit(items)
+			}
+			if cerr != nil {
+				return cerr
+			}
+		}
+		if errors.Is(err, io.EOF) || (err == nil && len(entries) == 0) {
+			return nil
+		}
+		if err != nil {
+			return &fs.PathError{Op: "readdir", Path: dir, Err: err}
+		}
+	}
+}
+
+// ReadDir lists the folder dir of fsys at once, like Stream.
+func ReadDir(fsys fs.FS, dir string) ([]Item, error) {
+	var items []Item
+	err := Stream(context.Background(), fsys, dir, DefaultBatchSize, func(batch []Item) {
+		items = append(items, batch...)
+	})
+	return items, err
+}
+
+func readItems(ctx context.Context, fsys fs.FS, dir string, entries []fs.DirEntry) ([]Item, error) {
+	items := make([]Item, 0, len(entries))
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return items, err
+		}
+
+		item := Item{
+			Name:  entry.Name(),
+			Path:  path.Join(dir, entry.Name()),
+			IsDir: entry.IsDir(),
+		}
+		info, err := entry.Info()
+		if err != nil {
+			item.Err = err
+			items = append(items, item)
+			continue
+		}
+		item.Size = info.Size()
+		item.ModTime = info.ModTime()
+
+		if entry.Type()&(fs.ModeSymlink|fs.ModeIrregular) != 0 {
+			if _, isLink, _ := readLink(fsys, item.Path); isLink {
+				item.Link = true
+				if target, err := fs.Stat(fsys, item.Path); err == nil {
+					item.IsDir = target.IsDir()
+				}
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
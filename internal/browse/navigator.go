@@ -0,0 +1,286 @@
+package browse
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+)
+
+// maxHistory bounds the back and forward stacks of a Navigator.
+const maxHistory = 100
+
+var (
+	ErrNoParent  = errors.New("already at the top folder")
+	ErrNoHistory = errors.New("no folder to go to")
+	// ErrOutsideRoot is returned for locations a confined navigator may
+	// not reach, including links that lead out of its root.
+	ErrOutsideRoot = errors.New("outside the drawer folder")
+)
+
+// Event reports a change of location. Paths are in the navigator's file
+// system.
+type Event struct {
+	From, To string
+}
+
+// Breadcrumb is a segment of the current location. Path is the location
+// the segment stands for.
+type Breadcrumb struct {
+	Name string
+	Path string
+}
+
+// Navigator tracks the folder a drawer window shows within a file system,
+// with back and forward history in the manner of a web browser. Locations
+// are slash-separated paths as used by io/fs; "." is the root.
+//
+// A navigator can be confined to a folder, which then becomes the top it
+// cannot leave: not by going up, not through breadcrumbs or a location
+// typed in, and not through symbolic links or junctions pointing
+// elsewhere.
+type Navigator struct {
+	fsys     fs.FS
+	current  string
+	back     []string
+	forward  []string
+	onChange func(Event)
+
+	// root is the folder the navigator is confined to, or "" when it is
+	// not; realRoot is root with links followed.
+	root     string
+	realRoot string
+}
+
+// NewNavigator returns a navigator showing the folder start of fsys.
+func NewNavigator(fsys fs.FS, start string) (*Navigator, error) {
+	n := &Navigator{fsys: fsys}
+	target, err := n.check(start)
+	if err != nil {
+		return nil, err
+	}
+	n.current = target
+	return n, nil
+}
+
+// OnChange sets the function called after every change of location.
+func (n *Navigator) OnChange(fn func(Event)) {
+	n.onChange = fn
+}
+
+// Confine keeps the navigator inside the folder root; an empty root lifts
+// the confinement. When the current location is outside root the
+// navigator moves to root. History entries outside root are dropped.
+func (n *Navigator) Confine(root string) error {
+	if root == "" {
+		n.root, n.realRoot = "", ""
+		return nil
+	}
+
+	n.root, n.realRoot = "", ""
+	target, err := n.check(root)
+	if err != nil {
+		return err
+	}
+	resolved, ok := realLocation(n.fsys, target)
+	if !ok {
+		return fmt.Errorf("%s: %w", root, ErrOutsideRoot)
+	}
+	n.root, n.realRoot = target, resolved
+
+	n.back = n.reachable(n.back)
+	n.forward = n.reachable(n.forward)
+	if !n.Reachable(n.current) {
+		n.move(n.root)
+	}
+	return nil
+}
+
+// Confined reports whether the navigator is confined to a folder.
+func (n *Navigator) Confined() bool {
+	return n.root != ""
+}
+
+// Top returns the location the navigator cannot go up from: its root when
+// confined, "." otherwise.
+func (n *Navigator) Top() string {
+	if n.root != "" {
+		return n.root
+	}
+	return "."
+}
+
+// Reachable reports whether the file or folder at location may be opened:
+// always for a navigator that is not confined, otherwise only when it lies
+// inside the root with every link on the way followed.
+func (n *Navigator) Reachable(location string) bool {
+	if n.root == "" {
+		return true
+	}
+
+	location = path.Clean(location)
+	if !within(n.root, location) {
+		return false
+	}
+	resolved, ok := realLocation(n.fsys, location)
+	return ok && within(n.realRoot, resolved)
+}
+
+// FS returns the file system the navigator walks.
+func (n *Navigator) FS() fs.FS {
+	return n.fsys
+}
+
+// Current returns the location shown.
+func (n *Navigator) Current() string {
+	return n.current
+}
+
+// ReadDir lists the current location.
+func (n *Navigator) ReadDir() ([]Item, error) {
+	return ReadDir(n.fsys, n.current)
+}
+
+// Go moves to the folder at location. The current location goes onto the
+// back stack and the forward stack is cleared.
+func (n *Navigator) Go(location string) error {
+	target, err := n.check(location)
+	if err != nil {
+		return err
+	}
+	if target == n.current {
+		return nil
+	}
+
+	n.back = pushHistory(n.back, n.current)
+	n.forward = nil
+	n.move(target)
+	return nil
+}
+
+// Up moves to the parent of the current location.
+func (n *Navigator) Up() error {
+	if !n.CanGoUp() {
+		return ErrNoParent
+	}
+	return n.Go(path.Dir(n.current))
+}
+
+// Back returns to the previous location.
+func (n *Navigator) Back() error {
+	if len(n.back) == 0 {
+		return ErrNoHistory
+	}
+
+	target, err := n.check(n.back[len(n.back)-1])
+	if err != nil {
+		return err
+	}
+	n.back = n.back[:len(n.back)-1]
+	n.forward = pushHistory(n.forward, n.current)
+	n.move(target)
+	return nil
+}
+
+// Forward undoes the last Back.
+func (n *Navigator) Forward() error {
+	if len(n.forward) == 0 {
+		return ErrNoHistory
+	}
+
+	target, err := n.check(n.forward[len(n.forward)-1])
+	if err != nil {
+		return err
+	}
+	n.forward = n.forward[:len(n.forward)-1]
+	n.back = pushHistory(n.back, n.current)
+	n.move(target)
+	return nil
+}
+
+func (n *Navigator) CanGoBack() bool    { return len(n.back) > 0 }
+func (n *Navigator) CanGoForward() bool { return len(n.forward) > 0 }
+func (n *Navigator) CanGoUp() bool      { return n.current != n.Top() }
+
+// Breadcrumbs returns the segments of the current location below Top,
+// starting with Top itself, whose Name is empty.
+func (n *Navigator) Breadcrumbs() []Breadcrumb {
+	top := n.Top()
+	crumbs := []Breadcrumb{{Name: "", Path: top}}
+	if n.current == top {
+		return crumbs
+	}
+
+	rel := n.current
+	if top != "." {
+		rel = strings.TrimPrefix(n.current, top+"/")
+	}
+	for i, name := range strings.Split(rel, "/") {
+		prev := crumbs[i].Path
+		crumbs = append(crumbs, Breadcrumb{Name: name, Path: path.Join(prev, name)})
+	}
+	return crumbs
+}
+
+// JumpTo moves to the i-th breadcrumb.
+func (n *Navigator) JumpTo(i int) error {
+	crumbs := n.Breadcrumbs()
+	if i < 0 || i >= len(crumbs) {
+		return fmt.Errorf("no breadcrumb %d", i)
+	}
+	return n.Go(crumbs[i].Path)
+}
+
+// check cleans location and makes sure it is a folder the navigator may
+// show.
+func (n *Navigator) check(location string) (string, error) {
+	target := path.Clean(location)
+	if !fs.ValidPath(target) {
+		return "", &fs.PathError{Op: "open", Path: location, Err: fs.ErrInvalid}
+	}
+	if !n.Reachable(target) {
+		return "", &fs.PathError{Op: "open", Path: location, Err: ErrOutsideRoot}
+	}
+
+	info, err := fs.Stat(n.fsys, target)
+	if err != nil {
+		return "", err
+	}
+	if !info.IsDir() {
+		return "", &fs.PathError{Op: "open", Path: location, Err: errors.New("not a folder")}
+	}
+	return target, nil
+}
+
+func (n *Navigator) move(target string) {
+	event := Event{From: n.current, To: target}
+	n.current = target
+	if n.onChange != nil {
+		n.onChange(event)
+	}
+}
+
+// reachable returns the entries of stack the navigator may go to.
+func (n *Navigator) reachable(stack []string) []string {
+	kept := stack[:0]
+	for _, location := range stack {
+		if n.Reachable(location) {
+			kept = append(kept, location)
+		}
+	}
+	return kept
+}
+
+func pushHistory(stack []string, location string) []string {
+	stack = append(stack, location)
+	if len(stack) > maxHistory {
+		stack = stack[len(stack)-maxHistory:]
+	}
+	return stack
+}
+
+// within reports whether location is dir or lies below it.
+func within(dir, location string) bool {
+	return dir == "." || location == dir || strings.HasPrefix(location, dir+"/")
+}
@@ -0,0 +1,397 @@
+package browse
+
+import (
+	"errors"
+	"io/fs"
+	"slices"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	folder := &fstest.MapFile{Mode: fs.ModeDir | 0o755}
+	link := func(target string) *fstest.MapFile {
+		return &fstest.MapFile{Data: []byte(target), Mode: fs.ModeSymlink | 0o777}
+	}
+
+	return fstest.MapFS{
+		"home":                         folder,
+		"home/other":                   folder,
+		"home/user":                    folder,
+		"home/user/docs":               folder,
+		"home/user/docs/reports":       folder,
+		"home/user/docs/reports/2024":  folder,
+		"home/user/docs/notes.txt":     &fstest.MapFile{Data: []byte("notes")},
+		"home/user/music":              folder,
+		"home/user/docs/to-music":      link("../music"),
+		"home/user/docs/escape":        link("../../other"),
+		"home/user/docs/absolute":      link("/etc"),
+		"home/user/docs/reports/cycle": link("../reports/cycle"),
+	}
+}
+
+func newTestNavigator(t *testing.T, start string) *Navigator {
+	t.Helper()
+	n, err := NewNavigator(testFS(), start)
+	if err != nil {
+		t.Fatalf("NewNavigator(%q): %v", start, err)
+	}
+	return n
+}
+
+func mustGo(t *testing.T, n *Navigator, location string) {
+	t.Helper()
+	if err := n.Go(location); err != nil {
+		t.Fatalf("Go(%q): %v", location, err)
+	}
+}
+
+func TestNewNavigator(t *testing.T) {
+	tests := []struct {
+		start string
+		want  string
+		err   error
+	}{
+		{".", ".", nil},
+		{"home/user/", "home/user", nil},
+		{"home/user/docs/../music", "home/user/music", nil},
+		{"home/missing", "", fs.ErrNotExist},
+		{"home/user/docs/notes.txt", "", nil},
+		{"/home", "", fs.ErrInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.start, func(t *testing.T) {
+			n, err := NewNavigator(testFS(), tt.start)
+			if tt.want == "" {
+				if err == nil {
+					t.Fatalf("NewNavigator(%q) = %q, want an error", tt.start, n.Current())
+				}
+				if tt.err != nil && !errors.Is(err, tt.err) {
+					t.Errorf("NewNavigator(%q) error = %v, want %v", tt.start, err, tt.err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("NewNavigator(%q): %v", tt.start, err)
+			}
+			if n.Current() != tt.want {
+				t.Errorf("Current() = %q, want %q", n.Current(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNavigatorHistory(t *testing.T) {
+	n := newTestNavigator(t, "home/user")
+
+	if err := n.Back(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Back() with no history = %v, want ErrNoHistory", err)
+	}
+	if err := n.Forward(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Forward() with no history = %v, want ErrNoHistory", err)
+	}
+
+	mustGo(t, n, "home/user/docs")
+	mustGo(t, n, "home/user/docs/reports")
+	mustGo(t, n, "home/user/docs/reports")
+
+	steps := []struct {
+		name string
+		move func() error
+		want string
+		back bool
+		fwd  bool
+	}{
+		{"back", n.Back, "home/user/docs", true, true},
+		{"back again", n.Back, "home/user", false, true},
+		{"forward", n.Forward, "home/user/docs", true, true},
+		{"up", n.Up, "home/user", true, false},
+		{"up again", n.Up, "home", true, false},
+		{"back after up", n.Back, "home/user", true, true},
+	}
+	for _, step := range steps {
+		if err := step.move(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		if n.Current() != step.want {
+			t.Errorf("%s: Current() = %q, want %q", step.name, n.Current(), step.want)
+		}
+		if n.CanGoBack() != step.back || n.CanGoForward() != step.fwd {
+			t.Errorf("%s: CanGoBack, CanGoForward = %v, %v, want %v, %v",
+				step.name, n.CanGoBack(), n.CanGoForward(), step.back, step.fwd)
+		}
+	}
+}
+
+func TestNavigatorGoClearsForward(t *testing.T) {
+	n := newTestNavigator(t, "home/user")
+	mustGo(t, n, "home/user/docs")
+	mustGo(t, n, "home/user/docs/reports")
+	if err := n.Back(); err != nil {
+		t.Fatal(err)
+	}
+	if err := n.Back(); err != nil {
+		t.Fatal(err)
+	}
+
+	mustGo(t, n, "home/user/music")
+	if n.CanGoForward() {
+		t.Errorf("CanGoForward() after Go = true, want false")
+	}
+	if err := n.Forward(); !errors.Is(err, ErrNoHistory) {
+		t.Errorf("Forward() after Go = %v, want ErrNoHistory", err)
+	}
+
+	if err := n.Back(); err != nil {
+		t.Fatal(err)
+	}
+	if n.Current() != "home/user" {
+		t.Errorf("Back() after Go went to %q, want home/user", n.Current())
+	}
+}
+
+func TestNavigatorHistoryLimit(t *testing.T) {
+	n := newTestNavigator(t, ".")
+	for i := range maxHistory + 10 {
+		location := "home"
+		if i%2 == 1 {
+			location = "home/user"
+		}
+		mustGo(t, n, location)
+	}
+
+	steps := 0
+	for n.CanGoBack() {
+		if err := n.Back(); err != nil {
+			t.Fatal(err)
+		}
+		steps++
+	}
+	if steps != maxHistory {
+		t.Errorf("went back %d times, want %d", steps, maxHistory)
+	}
+}
+
+func TestNavigatorUpAtTop(t *testing.T) {
+	n := newTestNavigator(t, ".")
+	if n.CanGoUp() {
+		t.Errorf("CanGoUp() at the top = true")
+	}
+	if err := n.Up(); !errors.Is(err, ErrNoParent) {
+		t.Errorf("Up() at the top = %v, want ErrNoParent", err)
+	}
+}
+
+func TestNavigatorBreadcrumbs(t *testing.T) {
+	tests := []struct {
+		name    string
+		root    string
+		current string
+		want    []Breadcrumb
+	}{
+		{
+			name:    "top",
+			current: ".",
+			want:    []Breadcrumb{{"", "."}},
+		},
+		{
+			name:    "nested",
+			current: "home/user/docs",
+			want:    []Breadcrumb{{"", "."}, {"home", "home"}, {"user", "home/user"}, {"docs", "home/user/docs"}},
+		},
+		{
+			name:    "confined at the root",
+			root:    "home/user",
+			current: "home/user",
+			want:    []Breadcrumb{{"", "home/user"}},
+		},
+		{
+			name:    "confined",
+			root:    "home/user",
+			current: "home/user/docs/reports",
+			want:    []Breadcrumb{{"", "home/user"}, {"docs", "home/user/docs"}, {"reports", "home/user/docs/reports"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n := newTestNavigator(t, tt.current)
+			if tt.root != "" {
+				if err := n.Confine(tt.root); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if got := n.Breadcrumbs(); !slices.Equal(got, tt.want) {
+				t.Errorf("Breadcrumbs() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNavigatorJumpTo(t *testing.T) {
+	n := newTestNavigator(t, "home/user/docs/reports")
+
+	if err := n.JumpTo(2); err != nil {
+		t.Fatal(err)
+	}
+	if n.Current() != "home/user" {
+		t.Errorf("JumpTo(2) went to %q, want home/user", n.Current())
+	}
+	if err := n.Back(); err != nil || n.Current() != "home/user/docs/reports" {
+		t.Errorf("Back() after JumpTo went to %q (%v), want home/user/docs/reports", n.Current(), err)
+	}
+
+	if err := n.JumpTo(0); err != nil || n.Current() != "." {
+		t.Errorf("JumpTo(0) went to %q (%v), want .", n.Current(), err)
+	}
+	for _, i := range []int{-1, 1} {
+		if err := n.JumpTo(i); err == nil {
+			t.Errorf("JumpTo(%d) at the top succeeded", i)
+		}
+	}
+}
+
+func TestNavigatorOnChange(t *testing.T) {
+	n := newTestNavigator(t, "home/user")
+	var events []Event
+	n.OnChange(func(e Event) { events = append(events, e) })
+
+	mustGo(t, n, "home/user/docs")
+	mustGo(t, n, "home/user/docs")
+	_ = n.Go("home/missing")
+	_ = n.Up()
+	_ = n.Back()
+	_ = n.Forward()
+	_ = n.JumpTo(1)
+
+	want := []Event{
+		{"home/user", "home/user/docs"},
+		{"home/user/docs", "home/user"},
+		{"home/user", "home/user/docs"},
+		{"home/user/docs", "home/user"},
+		{"home/user", "home"},
+	}
+	if !slices.Equal(events, want) {
+		t.Errorf("events = %v, want %v", events, want)
+	}
+}
+
+func TestNavigatorConfine(t *testing.T) {
+	n := newTestNavigator(t, "home/other")
+	mustGo(t, n, "home/user/docs")
+	mustGo(t, n, "home")
+	if err := n.Back(); err != nil {
+		t.Fatal(err)
+	}
+
+	var events []Event
+	n.OnChange(func(e Event) { events = append(events, e) })
+	if err := n.Confine("home/user"); err != nil {
+		t.Fatal(err)
+	}
+
+	// The current location is inside the root and stays; history outside
+	// it is dropped.
+	if n.Current() != "home/user/docs" || len(events) != 0 {
+		t.Errorf("Confine moved to %q (events %v), want to stay at home/user/docs", n.Current(), events)
+	}
+	if n.CanGoBack() || n.CanGoForward() {
+		t.Errorf("history outside the root was kept: back %v, forward %v", n.CanGoBack(), n.CanGoForward())
+	}
+	if !n.Confined() || n.Top() != "home/user" {
+		t.Errorf("Confined(), Top() = %v, %q, want true, home/user", n.Confined(), n.Top())
+	}
+
+	if err := n.Up(); err != nil {
+		t.Fatal(err)
+	}
+	if n.CanGoUp() {
+		t.Errorf("CanGoUp() at the root = true")
+	}
+	if err := n.Up(); !errors.Is(err, ErrNoParent) {
+		t.Errorf("Up() at the root = %v, want ErrNoParent", err)
+	}
+
+	if err := n.Confine(""); err != nil {
+		t.Fatal(err)
+	}
+	if n.Confined() || !n.CanGoUp() {
+		t.Errorf("Confine(\"\") left the navigator confined")
+	}
+}
+
+func TestNavigatorConfineMovesInside(t *testing.T) {
+	n := newTestNavigator(t, "home/other")
+	var events []Event
+	n.OnChange(func(e Event) { events = append(events, e) })
+
+	if err := n.Confine("home/user"); err != nil {
+		t.Fatal(err)
+	}
+	want := []Event{{"home/other", "home/user"}}
+	if n.Current() != "home/user" || !slices.Equal(events, want) {
+		t.Errorf("Confine moved to %q with events %v, want home/user with %v", n.Current(), events, want)
+	}
+}
+
+func TestNavigatorConfinedLocations(t *testing.T) {
+	tests := []struct {
+		location string
+		want     string
+		err      error
+	}{
+		{"home/user/docs", "home/user/docs", nil},
+		{"home/user/docs/to-music", "home/user/docs/to-music", nil},
+		{"home/user/docs/..", "home/user", nil},
+		{"home/user/..", "", ErrOutsideRoot},
+		{"home/other", "", ErrOutsideRoot},
+		{".", "", ErrOutsideRoot},
+		{"home/user-2", "", ErrOutsideRoot},
+		{"home/user/docs/escape", "", ErrOutsideRoot},
+		{"home/user/docs/absolute", "", ErrOutsideRoot},
+		{"home/user/docs/reports/cycle", "", ErrOutsideRoot},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			n := newTestNavigator(t, "home/user/docs/reports")
+			if err := n.Confine("home/user"); err != nil {
+				t.Fatal(err)
+			}
+
+			err := n.Go(tt.location)
+			if tt.err != nil {
+				if !errors.Is(err, tt.err) {
+					t.Errorf("Go(%q) = %v, want %v", tt.location, err, tt.err)
+				}
+				if n.Current() != "home/user/docs/reports" {
+					t.Errorf("Go(%q) moved to %q", tt.location, n.Current())
+				}
+				if n.Reachable(tt.location) {
+					t.Errorf("Reachable(%q) = true", tt.location)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Go(%q): %v", tt.location, err)
+			}
+			if n.Current() != tt.want {
+				t.Errorf("Go(%q) went to %q, want %q", tt.location, n.Current(), tt.want)
+			}
+		})
+	}
+}
+
+func TestNavigatorConfineToLink(t *testing.T) {
+	n := newTestNavigator(t, "home/user/docs/to-music")
+	if err := n.Confine("home/user/docs/to-music"); err != nil {
+		t.Fatal(err)
+	}
+	if !n.Reachable("home/user/docs/to-music") {
+		t.Errorf("root reached through a link is not reachable")
+	}
+	if n.Reachable("home/user/music") {
+		t.Errorf("link target outside the root's path is reachable")
+	}
+}
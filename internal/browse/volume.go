@@ -0,0 +1,71 @@
+package browse
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// VolumeFS is the file system of a volume of the host, e.g. C:\ or /.
+type VolumeFS struct {
+	// Root is the path of the volume.
+	Root string
+	fsys fs.FS
+}
+
+// Volume returns the file system of the volume holding the absolute path
+// p, and the location of p in it.
+func Volume(p string) (*VolumeFS, string, error) {
+	p = filepath.Clean(p)
+	if !filepath.IsAbs(p) {
+		return nil, "", fmt.Errorf("%s is not an absolute path", p)
+	}
+
+	v := &VolumeFS{Root: filepath.VolumeName(p) + string(filepath.Separator)}
+	v.fsys = os.DirFS(v.Root)
+	location, ok := v.Location(p)
+	if !ok {
+		return nil, "", fmt.Errorf("%s is not on %s", p, v.Root)
+	}
+	return v, location, nil
+}
+
+// Path turns a location into a path of the host.
+func (v *VolumeFS) Path(location string) string {
+	return filepath.Join(v.Root, filepath.FromSlash(location))
+}
+
+// Location returns where the absolute path p lies on the volume.
+func (v *VolumeFS) Location(p string) (string, bool) {
+	if !filepath.IsAbs(p) || !strings.EqualFold(filepath.VolumeName(p), filepath.VolumeName(v.Root)) {
+		return "", false
+	}
+	rel, err := filepath.Rel(v.Root, filepath.Clean(p))
+	if err != nil {
+		return "", false
+	}
+	location := filepath.ToSlash(rel)
+	return location, fs.ValidPath(location)
+}
+
+func (v *VolumeFS) Open(name string) (fs.File, error) {
+	return v.fsys.Open(name)
+}
+
+func (v *VolumeFS) Stat(name string) (fs.FileInfo, error) {
+	return fs.Stat(v.fsys, name)
+}
+
+func (v *VolumeFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(v.fsys, name)
+}
+
+func (v *VolumeFS) Lstat(name string) (fs.FileInfo, error) {
+	return fs.Lstat(v.fsys, name)
+}
+
+func (v *VolumeFS) ReadLink(name string) (string, error) {
+	return fs.ReadLink(v.fsys, name)
+}
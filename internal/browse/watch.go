@@ -0,0 +1,98 @@
+package browse
+
+import (
+	"encoding/binary"
+	"hash/fnv"
+	"io/fs"
+	"sync"
+	"time"
+)
+
+// Poller reports changes to a folder by listing it at an interval. It
+// stands in for change notifications where the host has none.
+type Poller struct {
+	fsys     fs.FS
+	dir      string
+	interval time.Duration
+	onChange func()
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// Poll starts listing the folder dir of fsys every interval. onChange runs
+// on the polling goroutine whenever an entry was added, removed or
+// modified since the last listing.
+func Poll(fsys fs.FS, dir string, interval time.Duration, onChange func()) *Poller {
+	p := &Poller{
+		fsys:     fsys,
+		dir:      dir,
+		interval: interval,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+	}
+	go p.loop()
+	return p
+}
+
+// Close ends polling without waiting for a listing in progress, which may
+// be slow on network folders; its result is dropped. It is safe to call
+// more than once.
+func (p *Poller) Close() error {
+	p.stopOnce.Do(func() { close(p.stop) })
+	return nil
+}
+
+func (p *Poller) loop() {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	known, _ := folderFingerprint(p.fsys, p.dir)
+	for {
+		select {
+		case <-p.stop:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := folderFingerprint(p.fsys, p.dir)
+		if err != nil || current == known {
+			continue
+		}
+		known = current
+
+		select {
+		case <-p.stop:
+			return
+		default:
+			p.onChange()
+		}
+	}
+}
+
+// folderFingerprint digests the names, sizes, times and modes of the
+// entries of dir.
+func folderFingerprint(fsys fs.FS, dir string) (uint64, error) {
+	entries, err := fs.ReadDir(fsys, dir)
+	if err != nil {
+		return 0, err
+	}
+
+	h := fnv.New64a()
+	var buf [8]byte
+	for _, entry := range entries {
+		h.Write([]byte(entry.Name()))
+		h.Write([]byte{0})
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		binary.LittleEndian.PutUint64(buf[:], uint64(info.Size()))
+		h.Write(buf[:])
+		binary.LittleEndian.PutUint64(buf[:], uint64(info.ModTime().UnixNano()))
+		h.Write(buf[:])
+		binary.LittleEndian.PutUint64(buf[:], uint64(info.Mode()))
+		h.Write(buf[:])
+	}
+	return h.Sum64(), nil
+}
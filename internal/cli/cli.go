@@ -0,0 +1,253 @@
+// Package cli implements the goDrawer subcommands that manage the settings
+// file without starting the GUI.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// Exit codes returned by Run.
+const (
+	ExitOK = 0
+	// ExitFailure means the command could not be carried out.
+	ExitFailure = 1
+	// ExitUsage means the command line was wrong.
+	ExitUsage = 2
+	// ExitInvalid means the settings file has problems; see config validate.
+	ExitInvalid = 3
+)
+
+// env is what a command runs against.
+type env struct {
+	configPath string
+	stdout     io.Writer
+	stderr     io.Writer
+}
+
+type command struct {
+	name  string
+	usage string
+	run   func(e *env, args []string) error
+}
+
+// groups lists the subcommands by their first word. A command without a
+// name is the whole group, as in doctor.
+var groups = map[string][]command{
+	"drawer": {
+		{"list", "drawer list [--format text|json]", drawerList},
+		{"add", "drawer add [--name NAME] [--group GROUP] [--profile PROFILE] [--confined=false] PATH", drawerAdd},
+		{"remove", "drawer remove DRAWER", drawerRemove},
+		{"rename", "drawer rename DRAWER NAME", drawerRename},
+	},
+	"theme": {
+		{"set", "theme set [--profile PROFILE] [--hue H] [--saturation S] [--lightness L] [--alpha A]", themeSet},
+	},
+	"config": {
+		{"show", "config show [--format text|toml|json] [--show-token]", configShow},
+		{"validate", "config validate [--strict]", configValidate},
+	},
+	"doctor": {
+		{"", "doctor [--fix] [--format text|json]", runDoctor},
+	},
+}
+
+var groupOrder = []string{"drawer", "theme", "config", "doctor"}
+
+// usageError marks errors in the command line itself. When the command
+// was not recognized, the list of commands is printed with it.
+type usageError struct {
+	msg          string
+	listCommands bool
+}
+
+func (e *usageError) Error() string {
+	return e.msg
+}
+
+func usagef(format string, args ...any) error {
+	return &usageError{msg: fmt.Sprintf(format, args...)}
+}
+
+// errInvalid is returned when the settings file failed validation. The
+// problems have been printed already.
+var errInvalid = errors.New("settings file has problems")
+
+// Run executes the subcommand in args against the settings file at
+// configPath and returns the process exit code.
+func Run(configPath string, args []string, stdout, stderr io.Writer) int {
+	e := &env{configPath: configPath, stdout: stdout, stderr: stderr}
+
+	err := e.dispatch(args)
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, flag.ErrHelp):
+		return ExitOK
+	case errors.Is(err, errInvalid):
+		fmt.Fprintf(stderr, "goDrawer: %v\n", err)
+		return ExitInvalid
+	}
+
+	fmt.Fprintf(stderr, "goDrawer: %v\n", err)
+	var usage *usageError
+	if errors.As(err, &usage) {
+		if usage.listCommands {
+			Usage(stderr)
+		}
+		return ExitUsage
+	}
+	return ExitFailure
+}
+
+func (e *env) dispatch(args []string) error {
+	unknown := func(format string, args ...any) error {
+		return &usageError{msg: fmt.Sprintf(format, args...), listCommands: true}
+	}
+
+	if len(args) == 0 {
+		return unknown("missing command")
+	}
+
+	commands, ok := groups[args[0]]
+	if !ok {
+		return unknown("unknown command %q", args[0])
+	}
+	if len(commands) == 1 && commands[0].name == "" {
+		return commands[0].run(e, args[1:])
+	}
+	if len(args) < 2 {
+		return unknown("missing %s subcommand", args[0])
+	}
+
+	for _, cmd := range commands {
+		if cmd.name == args[1] {
+			return cmd.run(e, args[2:])
+		}
+	}
+	return unknown("unknown command %q", args[0]+" "+args[1])
+}
+
+// Usage lists the subcommands.
+func Usage(w io.Writer) {
+	fmt.Fprintln(w, "Commands:")
+	for _, group := range groupOrder {
+		for _, cmd := range groups[group] {
+			fmt.Fprintf(w, "  goDrawer [--config FILE] %s\n", cmd.usage)
+		}
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "DRAWER is a drawer ID or name, PROFILE a profile ID or name.")
+}
+
+// flags returns a flag set for the subcommand described by usage. Flags
+// must come before positional arguments.
+func (e *env) flags(usage string) *flag.FlagSet {
+	fs := flag.NewFlagSet(usage, flag.ContinueOnError)
+	fs.SetOutput(e.stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(e.stderr, "Usage: goDrawer %s\n", usage)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parse parses args into fs and checks the number of positional arguments.
+func parse(fs *flag.FlagSet, args []string, positional int) error {
+	if err := fs.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			return err
+		}
+		return usagef("%s: %v", fs.Name(), err)
+	}
+	if fs.NArg() != positional {
+		return usagef("usage: goDrawer %s", fs.Name())
+	}
+	return nil
+}
+
+// read loads the settings file without changing it. Problems that do not
+// stop it from being read are left to config validate.
+func (e *env) read() (*settings.Settings, error) {
+	s, _, err := settings.Load(e.configPath)
+	return s, err
+}
+
+// update applies fn to the settings and writes them back. The change can
+// be undone from the GUI like any other.
+func (e *env) update(cause string, fn func(*settings.Settings) error) error {
+	s, report, err := settings.Read(e.configPath)
+	if err != nil {
+		return err
+	}
+	if report.Created {
+		fmt.Fprintf(e.stderr, "goDrawer: created settings file %s\n", e.configPath)
+	}
+
+	store := settings.NewStore(e.configPath, s, 0)
+	if err := store.Update(cause, fn); err != nil {
+		store.Close()
+		return err
+	}
+	if err := store.Close(); err != nil {
+		return fmt.Errorf("failed to write settings: %w", err)
+	}
+	return nil
+}
+
+// findDrawer looks a drawer up by ID or, case-insensitively, by name.
+func findDrawer(s *settings.Settings, ref string) (settings.Drawer, error) {
+	if i := s.DrawerIndex(ref); i >= 0 {
+		return s.Drawers[i], nil
+	}
+
+	var found []settings.Drawer
+	for _, drawer := range s.Drawers {
+		if strings.EqualFold(strings.TrimSpace(drawer.Name), strings.TrimSpace(ref)) {
+			found = append(found, drawer)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return settings.Drawer{}, fmt.Errorf("no drawer %q", ref)
+	case 1:
+		return found[0], nil
+	default:
+		return settings.Drawer{}, fmt.Errorf("%d drawers are named %q, use the drawer ID instead", len(found), ref)
+	}
+}
+
+// findProfile returns the ID of the profile with the given ID or name. The
+// shared base, called settings.BaseProfileName, has the empty ID.
+func findProfile(s *settings.Settings, ref string) (string, error) {
+	if strings.EqualFold(ref, settings.BaseProfileName) {
+		return "", nil
+	}
+	if s.ProfileIndex(ref) >= 0 {
+		return ref, nil
+	}
+	for _, profile := range s.Profiles {
+		if strings.EqualFold(profile.Name, ref) {
+			return profile.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no profile %q", ref)
+}
+
+// findGroup returns the ID of the group with the given ID or name.
+func findGroup(s *settings.Settings, ref string) (string, error) {
+	if s.GroupIndex(ref) >= 0 {
+		return ref, nil
+	}
+	for _, group := range s.Groups {
+		if strings.EqualFold(group.Name, ref) {
+			return group.ID, nil
+		}
+	}
+	return "", fmt.Errorf("no group %q", ref)
+}
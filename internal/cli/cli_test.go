@@ -0,0 +1,135 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// writeConfig writes a settings file with contents into a new temporary
+// directory and returns its path. No baseline is merged under it.
+func writeConfig(t *testing.T, contents string) string {
+	t.Helper()
+	t.Setenv(settings.EnvBaselinePath, "")
+
+	path := filepath.Join(t.TempDir(), settings.FileName)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// run runs args against the settings file at path and returns the exit
+// code and what was written to stdout and stderr.
+func run(path string, args ...string) (int, string, string) {
+	var stdout, stderr bytes.Buffer
+	code := Run(path, args, &stdout, &stderr)
+	return code, stdout.String(), stderr.String()
+}
+
+// assertUnchanged fails unless the directory of path only holds the
+// settings file and it still contains want.
+func assertUnchanged(t *testing.T, path, want string) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != want {
+		t.Errorf("settings file changed to %q", data)
+	}
+	entries, err := os.ReadDir(filepath.Dir(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the settings file", len(entries))
+	}
+}
+
+// oldConfig predates schema_version and drawer ids, so reading it with
+// settings.Read would rewrite it.
+const oldConfig = "[control]\nenabled = true\ntoken = \"secret\"\n\n[[drawers]]\nname = \"Docs\"\npath = \"/docs\"\n"
+
+func TestReadCommandsLeaveFileAlone(t *testing.T) {
+	for _, args := range [][]string{
+		{"config", "show"},
+		{"config", "show", "--format", "toml"},
+		{"config", "validate"},
+		{"drawer", "list"},
+		{"doctor"},
+	} {
+		path := writeConfig(t, oldConfig)
+		run(path, args...)
+		assertUnchanged(t, path, oldConfig)
+	}
+}
+
+func TestReadCommandsNeedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), settings.FileName)
+	t.Setenv(settings.EnvBaselinePath, "")
+
+	for _, args := range [][]string{{"config", "show"}, {"config", "validate"}, {"drawer", "list"}} {
+		if code, _, _ := run(path, args...); code != ExitFailure {
+			t.Errorf("%v exited with %d, want %d", args, code, ExitFailure)
+		}
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Fatalf("%v created the settings file: %v", args, err)
+		}
+	}
+}
+
+func TestConfigShowRedactsToken(t *testing.T) {
+	path := writeConfig(t, oldConfig)
+
+	for _, format := range []string{"toml", "json"} {
+		code, stdout, _ := run(path, "config", "show", "--format", format)
+		if code != ExitOK || strings.Contains(stdout, "secret") {
+			t.Errorf("config show --format %s = %d, %q; want the token redacted", format, code, stdout)
+		}
+
+		code, stdout, _ = run(path, "config", "show", "--format", format, "--show-token")
+		if code != ExitOK || !strings.Contains(stdout, "secret") {
+			t.Errorf("config show --format %s --show-token = %d, %q; want the token", format, code, stdout)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	tests := []struct {
+		name     string
+		contents string
+		args     []string
+		want     int
+	}{
+		{"valid", "schema_version = 2\n", nil, ExitOK},
+		{"not toml", "[[drawers]\n", nil, ExitInvalid},
+		{"invalid value", "schema_version = 2\ntheme = { h = 400, s = 50, l = 50, a = 100 }\n", nil, ExitInvalid},
+		{"unknown key", "schema_version = 2\nthumbnail_sise = 1\n", nil, ExitOK},
+		{"unknown key strict", "schema_version = 2\nthumbnail_sise = 1\n", []string{"--strict"}, ExitInvalid},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeConfig(t, tt.contents)
+			args := append([]string{"config", "validate"}, tt.args...)
+			if code, stdout, stderr := run(path, args...); code != tt.want {
+				t.Errorf("exit code = %d, want %d\nstdout: %s\nstderr: %s", code, tt.want, stdout, stderr)
+			}
+			assertUnchanged(t, path, tt.contents)
+		})
+	}
+}
+
+func TestUsageListsFlags(t *testing.T) {
+	var buf bytes.Buffer
+	Usage(&buf)
+	for _, flag := range []string{"--confined=false", "--show-token"} {
+		if !strings.Contains(buf.String(), flag) {
+			t.Errorf("usage does not mention %s:\n%s", flag, buf.String())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package cli
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+func configShow(e *env, args []string) error {
+	fs := e.flags("config show [--format text|toml|json] [--show-token]")
+	name := fs.String("format", "text", "output format: text, toml or json")
+	showToken := fs.Bool("show-token", false, "include the control API token")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	format, err := settings.ParseFormat(*name)
+	if err != nil {
+		return usagef("config show: %v", err)
+	}
+
+	s, err := e.read()
+	if err != nil {
+		return err
+	}
+	return settings.Export(e.stdout, s, format, *showToken)
+}
+
+// configValidate checks the settings file as goDrawer would at startup.
+// It never writes the file: pending migrations are only reported, and
+// unlike the GUI it never falls back to a backup, so a file that does not
+// parse fails.
+func configValidate(e *env, args []string) error {
+	fs := e.flags("config validate [--strict]")
+	strict := fs.Bool("strict", false, "treat unknown keys as problems")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(e.configPath); err != nil {
+		return fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	s, report, err := settings.Load(e.configPath)
+	if err != nil {
+		return fmt.Errorf("%w: %v", errInvalid, err)
+	}
+	settings.FprintReport(e.stdout, report)
+
+	invalid := *strict && len(report.UnknownKeys) > 0
+	if err := s.Validate(); err != nil {
+		settings.FprintValidation(e.stdout, err, settings.Strict)
+		invalid = true
+	}
+
+	if invalid {
+		return errInvalid
+	}
+	fmt.Fprintf(e.stdout, "%s is valid.\n", e.configPath)
+	return nil
+}
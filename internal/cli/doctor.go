@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/deadlyedge/goDrawer/internal/assets"
+	"github.com/deadlyedge/goDrawer/internal/doctor"
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// finding is a doctor finding as printed by doctor --format json.
+type finding struct {
+	Severity string `json:"severity"`
+	Subject  string `json:"subject"`
+	Problem  string `json:"problem"`
+	Fix      string `json:"fix"`
+	Safe     bool   `json:"safe"`
+	Fixed    bool   `json:"fixed"`
+}
+
+func runDoctor(e *env, args []string) error {
+	fs := e.flags("doctor [--fix] [--format text|json]")
+	fix := fs.Bool("fix", false, "apply the fixes that are safe to make automatically")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown format %q, expected text or json", *format)
+	}
+
+	opts := doctor.Options{FontPath: assets.Path(assets.BrandFont)}
+
+	var findings []*doctor.Finding
+	if *fix {
+		err := e.update("doctor", func(s *settings.Settings) error {
+			findings = doctor.Check(s, opts)
+			doctor.Apply(s, findings)
+			return nil
+		})
+		if err != nil {
+			return fmt.Errorf("failed to apply fixes: %w", err)
+		}
+	} else {
+		s, err := e.read()
+		if err != nil {
+			return err
+		}
+		findings = doctor.Check(s, opts)
+	}
+
+	if *format == "json" {
+		out := make([]finding, len(findings))
+		for i, f := range findings {
+			out[i] = finding{Severity: f.Severity.String(), Subject: f.Subject, Problem: f.Problem, Fix: f.Fix, Safe: f.Safe(), Fixed: f.Fixed}
+		}
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(out); err != nil {
+			return err
+		}
+	} else {
+		printFindings(e, findings, *fix)
+	}
+
+	for _, f := range findings {
+		if !f.Fixed {
+			return errInvalid
+		}
+	}
+	return nil
+}
+
+func printFindings(e *env, findings []*doctor.Finding, fixing bool) {
+	if len(findings) == 0 {
+		fmt.Fprintln(e.stdout, "No problems found.")
+		return
+	}
+
+	fixed, safe := 0, 0
+	for _, f := range findings {
+		fmt.Fprintf(e.stdout, "%-8s %s\n", f.Severity, f)
+		switch {
+		case f.Fixed:
+			fixed++
+			fmt.Fprintf(e.stdout, "         fixed: %s\n", f.Fix)
+		case f.Safe():
+			safe++
+			fmt.Fprintf(e.stdout, "         fix (automatic with --fix): %s\n", f.Fix)
+		default:
+			fmt.Fprintf(e.stdout, "         fix: %s\n", f.Fix)
+		}
+	}
+
+	fmt.Fprintln(e.stdout)
+	fmt.Fprintf(e.stdout, "%d problems found", len(findings))
+	if fixing {
+		fmt.Fprintf(e.stdout, ", %d fixed", fixed)
+	} else if safe > 0 {
+		fmt.Fprintf(e.stdout, ", %d can be fixed with goDrawer doctor --fix", safe)
+	}
+	fmt.Fprintln(e.stdout, ".")
+}
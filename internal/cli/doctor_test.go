@@ -0,0 +1,92 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/deadlyedge/goDrawer/internal/assets"
+)
+
+// withFont makes the working directory one holding a usable brand font, so
+// that doctor only reports the problems of the settings file.
+func withFont(t *testing.T) {
+	t.Helper()
+	dir := t.TempDir()
+	font := filepath.Join(dir, assets.BrandFont)
+	if err := os.MkdirAll(filepath.Dir(font), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(font, []byte("\x00\x01\x00\x00"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Chdir(dir)
+}
+
+func TestDoctor(t *testing.T) {
+	withFont(t)
+	folder := t.TempDir()
+	drawer := fmt.Sprintf("[[drawers]]\nid = \"d1\"\nname = \"Docs\"\npath = %q\n", folder)
+	missing := fmt.Sprintf("[[drawers]]\nid = \"d1\"\nname = \"Docs\"\npath = %q\n", filepath.Join(folder, "missing"))
+
+	tests := []struct {
+		name     string
+		contents string
+		args     []string
+		want     int
+		output   string
+	}{
+		{"no problems", drawer, nil, ExitOK, "No problems found."},
+		{"missing folder", missing, nil, ExitInvalid, "does not exist"},
+		{"missing folder with fix", missing, []string{"--fix"}, ExitInvalid, "0 fixed"},
+		{"safe fix", "theme = { h = 400, s = 50, l = 50, a = 100 }\n", nil, ExitInvalid, "1 can be fixed with goDrawer doctor --fix"},
+		{"bad format", drawer, []string{"--format", "yaml"}, ExitUsage, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			contents := "schema_version = 2\n" + tt.contents
+			path := writeConfig(t, contents)
+			code, stdout, stderr := run(path, append([]string{"doctor"}, tt.args...)...)
+			if code != tt.want || !strings.Contains(stdout, tt.output) {
+				t.Errorf("exit code = %d, want %d with %q\nstdout: %s\nstderr: %s", code, tt.want, tt.output, stdout, stderr)
+			}
+			if slices.Contains(tt.args, "--fix") {
+				return
+			}
+			assertUnchanged(t, path, contents)
+		})
+	}
+}
+
+func TestDoctorFix(t *testing.T) {
+	withFont(t)
+	path := writeConfig(t, "schema_version = 2\n# hand-tuned\ntheme = { h = 400, s = 50, l = 50, a = 100 }\n")
+
+	code, stdout, stderr := run(path, "doctor", "--fix", "--format", "json")
+	if code != ExitOK {
+		t.Fatalf("doctor --fix exited with %d\nstdout: %s\nstderr: %s", code, stdout, stderr)
+	}
+	var findings []finding
+	if err := json.Unmarshal([]byte(stdout), &findings); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout)
+	}
+	if len(findings) != 1 || findings[0].Subject != "theme.h" || !findings[0].Safe || !findings[0].Fixed {
+		t.Errorf("findings = %+v, want theme.h fixed", findings)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "# hand-tuned") || !strings.Contains(string(data), "h = 360") {
+		t.Errorf("settings file after fix:\n%s", data)
+	}
+
+	if code, stdout, _ := run(path, "doctor"); code != ExitOK {
+		t.Errorf("doctor after fix exited with %d:\n%s", code, stdout)
+	}
+}
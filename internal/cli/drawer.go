@@ -0,0 +1,162 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"text/tabwriter"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// drawerInfo is a drawer as listed by drawer list --format json.
+type drawerInfo struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	ResolvedPath string `json:"resolved_path"`
+	Group        string `json:"group,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+	Confined     bool   `json:"confined"`
+}
+
+func drawerList(e *env, args []string) error {
+	fs := e.flags("drawer list [--format text|json]")
+	format := fs.String("format", "text", "output format: text or json")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+	if *format != "text" && *format != "json" {
+		return usagef("unknown format %q, expected text or json", *format)
+	}
+
+	s, err := e.read()
+	if err != nil {
+		return err
+	}
+
+	infos := make([]drawerInfo, len(s.Drawers))
+	for i, drawer := range s.Drawers {
+		infos[i] = drawerInfo{
+			ID:           drawer.ID,
+			Name:         drawer.Name,
+			Path:         drawer.Path,
+			ResolvedPath: s.ResolvePath(drawer.Path),
+			Group:        groupName(s, drawer.Group),
+			Profile:      profileName(s, drawer.Profile),
+			Confined:     drawer.Confined,
+		}
+	}
+
+	if *format == "json" {
+		encoder := json.NewEncoder(e.stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(infos)
+	}
+
+	tw := tabwriter.NewWriter(e.stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tPATH\tGROUP\tPROFILE")
+	for _, info := range infos {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n", info.ID, info.Name, info.Path, info.Group, info.Profile)
+	}
+	return tw.Flush()
+}
+
+func drawerAdd(e *env, args []string) error {
+	fs := e.flags("drawer add [--name NAME] [--group GROUP] [--profile PROFILE] [--confined=false] PATH")
+	name := fs.String("name", "", "drawer name (default: the folder name)")
+	group := fs.String("group", "", "group ID or name")
+	profile := fs.String("profile", "", "profile ID or name (default: shared by every profile)")
+	confined := fs.Bool("confined", true, "keep the drawer window inside the folder")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	// Relative paths in the settings file start at its directory, so the
+	// folder is stored as found from the working directory.
+	abs, err := filepath.Abs(settings.ExpandPath(fs.Arg(0), ""))
+	if err != nil {
+		return fmt.Errorf("failed to resolve %s: %w", fs.Arg(0), err)
+	}
+	path := settings.ContractPath(abs)
+	if *name == "" {
+		*name = filepath.Base(abs)
+	}
+
+	var added settings.Drawer
+	err = e.update("add drawer", func(s *settings.Settings) error {
+		drawer := settings.Drawer{Name: *name, Path: path, Confined: *confined}
+		var err error
+		if *group != "" {
+			if drawer.Group, err = findGroup(s, *group); err != nil {
+				return err
+			}
+		}
+		if *profile != "" {
+			if drawer.Profile, err = findProfile(s, *profile); err != nil {
+				return err
+			}
+		}
+
+		added, err = s.AddDrawer(drawer)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add drawer: %w", err)
+	}
+
+	fmt.Fprintln(e.stdout, added.ID)
+	return nil
+}
+
+func drawerRemove(e *env, args []string) error {
+	fs := e.flags("drawer remove DRAWER")
+	if err := parse(fs, args, 1); err != nil {
+		return err
+	}
+
+	err := e.update("remove drawer", func(s *settings.Settings) error {
+		drawer, err := findDrawer(s, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return s.RemoveDrawer(drawer.ID)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to remove drawer: %w", err)
+	}
+	return nil
+}
+
+func drawerRename(e *env, args []string) error {
+	fs := e.flags("drawer rename DRAWER NAME")
+	if err := parse(fs, args, 2); err != nil {
+		return err
+	}
+
+	err := e.update("rename drawer", func(s *settings.Settings) error {
+		drawer, err := findDrawer(s, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		return s.RenameDrawer(drawer.ID, fs.Arg(1))
+	})
+	if err != nil {
+		return fmt.Errorf("failed to rename drawer: %w", err)
+	}
+	return nil
+}
+
+func groupName(s *settings.Settings, id string) string {
+	if i := s.GroupIndex(id); i >= 0 {
+		return s.Groups[i].Name
+	}
+	return id
+}
+
+func profileName(s *settings.Settings, id string) string {
+	if i := s.ProfileIndex(id); i >= 0 {
+		return s.Profiles[i].Name
+	}
+	return id
+}
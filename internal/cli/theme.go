@@ -0,0 +1,86 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// themeFlags names the flag of theme set for each key of a theme.
+var themeFlags = map[string]string{"h": "hue", "s": "saturation", "l": "lightness", "a": "alpha"}
+
+func themeSet(e *env, args []string) error {
+	fs := e.flags("theme set [--profile PROFILE] [--hue H] [--saturation S] [--lightness L] [--alpha A]")
+	profile := fs.String("profile", "", "profile ID or name (default: the active profile)")
+	hue := fs.Int("hue", 0, "hue, 0-360")
+	saturation := fs.Int("saturation", 0, "saturation, 0-100")
+	lightness := fs.Int("lightness", 0, "lightness, 0-100")
+	alpha := fs.Int("alpha", 0, "alpha, 0-100")
+	if err := parse(fs, args, 0); err != nil {
+		return err
+	}
+
+	given := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { given[f.Name] = true })
+	if !given["hue"] && !given["saturation"] && !given["lightness"] && !given["alpha"] {
+		return usagef("theme set: nothing to set")
+	}
+
+	// Flags left out are zero, which is in range.
+	values := settings.Theme{Hue: *hue, Saturation: *saturation, Lightness: *lightness, Alpha: *alpha}
+	if err := settings.ValidateTheme(values); err != nil {
+		var invalid *settings.ValidationError
+		if errors.As(err, &invalid) {
+			problem := invalid.Problems[0]
+			return usagef("theme set: --%s %s", themeFlags[problem.Field], problem.Message)
+		}
+		return usagef("theme set: %v", err)
+	}
+
+	err := e.update("theme", func(s *settings.Settings) error {
+		id := s.ActiveProfileID()
+		if *profile != "" {
+			var err error
+			if id, err = findProfile(s, *profile); err != nil {
+				return err
+			}
+		}
+
+		// A profile without a theme of its own shows the base theme, so
+		// that is what the new values change.
+		theme := s.Theme
+		var p *settings.Profile
+		if i := s.ProfileIndex(id); i >= 0 {
+			p = &s.Profiles[i]
+			if p.Theme != nil {
+				theme = *p.Theme
+			}
+		}
+
+		if given["hue"] {
+			theme.Hue = *hue
+		}
+		if given["saturation"] {
+			theme.Saturation = *saturation
+		}
+		if given["lightness"] {
+			theme.Lightness = *lightness
+		}
+		if given["alpha"] {
+			theme.Alpha = *alpha
+		}
+
+		if p != nil {
+			p.Theme = &theme
+		} else {
+			s.Theme = theme
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to set theme: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,268 @@
+// Package control serves the local control API: a small JSON-over-HTTP
+// interface on the loopback address that lets scripts and other tools drive
+// a running goDrawer. Every request must carry the configured token as
+// "Authorization: Bearer <token>".
+package control
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// ErrNotFound is returned by a Controller for a drawer it does not know.
+var ErrNotFound = errors.New("drawer not found")
+
+// Drawer is a drawer as reported by the API.
+type Drawer struct {
+	ID           string `json:"id"`
+	Name         string `json:"name"`
+	Path         string `json:"path"`
+	ResolvedPath string `json:"resolved_path"`
+	Group        string `json:"group,omitempty"`
+	Profile      string `json:"profile,omitempty"`
+	Confined     bool   `json:"confined"`
+	// Visible is false for drawers of other profiles.
+	Visible bool `json:"visible"`
+	Open    bool `json:"open"`
+	// Location is the folder an open drawer window shows.
+	Location string `json:"location,omitempty"`
+}
+
+// ThemeUpdate changes the given HSLA components of the active theme.
+type ThemeUpdate struct {
+	Hue        *int `json:"h"`
+	Saturation *int `json:"s"`
+	Lightness  *int `json:"l"`
+	Alpha      *int `json:"a"`
+}
+
+// Apply returns theme with the components of u that are set.
+func (u ThemeUpdate) Apply(theme settings.Theme) settings.Theme {
+	for _, part := range []struct {
+		value *int
+		dst   *int
+	}{{u.Hue, &theme.Hue}, {u.Saturation, &theme.Saturation}, {u.Lightness, &theme.Lightness}, {u.Alpha, &theme.Alpha}} {
+		if part.value != nil {
+			*part.dst = *part.value
+		}
+	}
+	return theme
+}
+
+// Controller carries out API requests. Drawers are referred to by ID or
+// name. The implementation is responsible for running them on the right
+// thread.
+type Controller interface {
+	Drawers() ([]Drawer, error)
+	OpenDrawer(ref string) error
+	CloseDrawer(ref string) error
+	// Navigate opens the drawer if needed and shows subpath, which is
+	// relative to the drawer folder.
+	Navigate(ref, subpath string) error
+	// ToggleMainWindowVisibility returns whether the main window is now
+	// visible.
+	ToggleMainWindowVisibility() (bool, error)
+	ReloadSettings() error
+	SetTheme(update ThemeUpdate) (settings.Theme, error)
+}
+
+// Server is a running control API.
+type Server struct {
+	http     *http.Server
+	listener net.Listener
+}
+
+// Start listens on 127.0.0.1:port and serves requests for ctrl until Close.
+func Start(port int, token string, ctrl Controller) (*Server, error) {
+	if token == "" {
+		return nil, errors.New("control API needs a token")
+	}
+
+	listener, err := net.Listen("tcp", net.JoinHostPort("127.0.0.1", strconv.Itoa(port)))
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for control requests: %w", err)
+	}
+
+	h := &handler{token: token, ctrl: ctrl, port: strconv.Itoa(listener.Addr().(*net.TCPAddr).Port)}
+	srv := &Server{
+		http: &http.Server{
+			Handler:           h.routes(),
+			ReadHeaderTimeout: 5 * time.Second,
+		},
+		listener: listener,
+	}
+
+	go func() {
+		if err := srv.http.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("control API stopped: %v", err)
+		}
+	}()
+	return srv, nil
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() string {
+	return s.listener.Addr().String()
+}
+
+// Close stops the server, giving running requests a moment to finish.
+func (s *Server) Close() error {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	return s.http.Shutdown(ctx)
+}
+
+type handler struct {
+	token string
+	ctrl  Controller
+	port  string
+}
+
+func (h *handler) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /v1/drawers", h.listDrawers)
+	mux.HandleFunc("POST /v1/drawers/{drawer}/open", h.openDrawer)
+	mux.HandleFunc("POST /v1/drawers/{drawer}/close", h.closeDrawer)
+	mux.HandleFunc("POST /v1/drawers/{drawer}/navigate", h.navigate)
+	mux.HandleFunc("POST /v1/main-window/toggle", h.toggleMainWindow)
+	mux.HandleFunc("POST /v1/settings/reload", h.reload)
+	mux.HandleFunc("PUT /v1/theme", h.setTheme)
+	return h.authorize(mux)
+}
+
+// authorize checks the token, and the Host header so that web pages cannot
+// reach the API through DNS rebinding.
+func (h *handler) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host, port, err := net.SplitHostPort(r.Host)
+		if err != nil || port != h.port || (host != "127.0.0.1" && host != "localhost") {
+			writeError(w, http.StatusForbidden, errors.New("requests must be addressed to the loopback host"))
+			return
+		}
+
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeError(w, http.StatusUnauthorized, errors.New("missing or wrong token"))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (h *handler) listDrawers(w http.ResponseWriter, r *http.Request) {
+	drawers, err := h.ctrl.Drawers()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"drawers": drawers})
+}
+
+func (h *handler) openDrawer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.ctrl.OpenDrawer(r.PathValue("drawer")))
+}
+
+func (h *handler) closeDrawer(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.ctrl.CloseDrawer(r.PathValue("drawer")))
+}
+
+func (h *handler) navigate(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Subpath string `json:"subpath"`
+	}
+	if !decode(w, r, &body) {
+		return
+	}
+	h.respond(w, h.ctrl.Navigate(r.PathValue("drawer"), body.Subpath))
+}
+
+func (h *handler) toggleMainWindow(w http.ResponseWriter, r *http.Request) {
+	visible, err := h.ctrl.ToggleMainWindowVisibility()
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"visible": visible})
+}
+
+func (h *handler) reload(w http.ResponseWriter, r *http.Request) {
+	h.respond(w, h.ctrl.ReloadSettings())
+}
+
+func (h *handler) setTheme(w http.ResponseWriter, r *http.Request) {
+	var update ThemeUpdate
+	if !decode(w, r, &update) {
+		return
+	}
+
+	theme, err := h.ctrl.SetTheme(update)
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"h": theme.Hue, "s": theme.Saturation, "l": theme.Lightness, "a": theme.Alpha})
+}
+
+func (h *handler) respond(w http.ResponseWriter, err error) {
+	if err != nil {
+		writeError(w, statusFor(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"ok": true})
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return false
+	}
+	return true
+}
+
+// BadRequest marks controller errors caused by the request, such as a
+// theme value out of range.
+type BadRequest struct {
+	Err error
+}
+
+func (e *BadRequest) Error() string { return e.Err.Error() }
+func (e *BadRequest) Unwrap() error { return e.Err }
+
+func statusFor(err error) int {
+	var bad *BadRequest
+	switch {
+	case errors.Is(err, ErrNotFound):
+		return http.StatusNotFound
+	case errors.As(err, &bad):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+func writeError(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, map[string]any{"error": err.Error()})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("failed to write control response: %v", err)
+	}
+}
@@ -0,0 +1,177 @@
+package control
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+const testToken = "secret"
+
+// fakeController records the drawers it was asked to open.
+type fakeController struct {
+	opened []string
+	theme  settings.Theme
+}
+
+func (c *fakeController) Drawers() ([]Drawer, error) {
+	return []Drawer{{ID: "d1", Name: "Docs", Visible: true}}, nil
+}
+
+func (c *fakeController) OpenDrawer(ref string) error {
+	if ref != "d1" && ref != "Docs" {
+		return ErrNotFound
+	}
+	c.opened = append(c.opened, ref)
+	return nil
+}
+
+func (c *fakeController) CloseDrawer(ref string) error              { return nil }
+func (c *fakeController) Navigate(ref, subpath string) error        { return nil }
+func (c *fakeController) ToggleMainWindowVisibility() (bool, error) { return true, nil }
+func (c *fakeController) ReloadSettings() error                     { return nil }
+
+func (c *fakeController) SetTheme(update ThemeUpdate) (settings.Theme, error) {
+	theme := update.Apply(c.theme)
+	if err := settings.ValidateTheme(theme); err != nil {
+		return settings.Theme{}, &BadRequest{err}
+	}
+	c.theme = theme
+	return theme, nil
+}
+
+// serve sends a request through the API handler listening on port 47813
+// and returns the recorded response.
+func serve(ctrl Controller, method, target, host, auth, body string) *httptest.ResponseRecorder {
+	h := &handler{token: testToken, ctrl: ctrl, port: "47813"}
+
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Host = host
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	h.routes().ServeHTTP(w, r)
+	return w
+}
+
+func TestAuthorize(t *testing.T) {
+	tests := []struct {
+		name string
+		host string
+		auth string
+		want int
+	}{
+		{"valid", "127.0.0.1:47813", "Bearer " + testToken, http.StatusOK},
+		{"localhost", "localhost:47813", "Bearer " + testToken, http.StatusOK},
+		{"no token", "127.0.0.1:47813", "", http.StatusUnauthorized},
+		{"wrong token", "127.0.0.1:47813", "Bearer wrong", http.StatusUnauthorized},
+		{"token prefix", "127.0.0.1:47813", "Bearer " + testToken[:3], http.StatusUnauthorized},
+		{"not bearer", "127.0.0.1:47813", "Basic " + testToken, http.StatusUnauthorized},
+		{"rebound host", "attacker.example:47813", "Bearer " + testToken, http.StatusForbidden},
+		{"other port", "127.0.0.1:80", "Bearer " + testToken, http.StatusForbidden},
+		{"no port", "127.0.0.1", "Bearer " + testToken, http.StatusForbidden},
+		// The Host header is checked before the token, so a rebound page
+		// cannot probe it.
+		{"rebound host without token", "attacker.example:47813", "", http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(&fakeController{}, "GET", "/v1/drawers", tt.host, tt.auth, "")
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") != "Bearer" {
+				t.Errorf("WWW-Authenticate = %q, want Bearer", w.Header().Get("WWW-Authenticate"))
+			}
+		})
+	}
+}
+
+func TestRejectedRequestsDoNotReachController(t *testing.T) {
+	ctrl := &fakeController{}
+	serve(ctrl, "POST", "/v1/drawers/d1/open", "127.0.0.1:47813", "Bearer wrong", "")
+	serve(ctrl, "POST", "/v1/drawers/d1/open", "attacker.example:47813", "Bearer "+testToken, "")
+	if len(ctrl.opened) != 0 {
+		t.Errorf("opened %q through rejected requests", ctrl.opened)
+	}
+
+	serve(ctrl, "POST", "/v1/drawers/Docs/open", "127.0.0.1:47813", "Bearer "+testToken, "")
+	if len(ctrl.opened) != 1 || ctrl.opened[0] != "Docs" {
+		t.Errorf("opened %q, want [Docs]", ctrl.opened)
+	}
+}
+
+func TestStatusCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		target string
+		body   string
+		want   int
+	}{
+		{"unknown drawer", "POST", "/v1/drawers/nope/open", "", http.StatusNotFound},
+		{"theme", "PUT", "/v1/theme", `{"h": 120}`, http.StatusOK},
+		{"theme out of range", "PUT", "/v1/theme", `{"h": 400}`, http.StatusBadRequest},
+		{"unknown field", "PUT", "/v1/theme", `{"hue": 120}`, http.StatusBadRequest},
+		{"wrong method", "GET", "/v1/theme", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := serve(&fakeController{theme: settings.DefaultTheme()}, tt.method, tt.target, "127.0.0.1:47813", "Bearer "+testToken, tt.body)
+			if w.Code != tt.want {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestStart(t *testing.T) {
+	if _, err := Start(0, "", &fakeController{}); err == nil {
+		t.Fatal("Start without a token succeeded")
+	}
+
+	srv, err := Start(0, testToken, &fakeController{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+
+	get := func(auth string) (int, string) {
+		req, err := http.NewRequest("GET", fmt.Sprintf("http://%s/v1/drawers", srv.Addr()), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if auth != "" {
+			req.Header.Set("Authorization", auth)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		body, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, string(body)
+	}
+
+	if code, _ := get(""); code != http.StatusUnauthorized {
+		t.Errorf("status without token = %d, want %d", code, http.StatusUnauthorized)
+	}
+
+	code, body := get("Bearer " + testToken)
+	if code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", code, http.StatusOK, body)
+	}
+	var reply struct {
+		Drawers []Drawer `json:"drawers"`
+	}
+	if err := json.Unmarshal([]byte(body), &reply); err != nil || len(reply.Drawers) != 1 || reply.Drawers[0].ID != "d1" {
+		t.Errorf("body = %s (%v), want drawer d1", body, err)
+	}
+}
@@ -0,0 +1,309 @@
+// Package doctor looks for the configuration problems behind "drawer shows
+// nothing" and "icons missing": folders and icons that do not exist, an
+// unusable brand font and theme values out of range. Each finding comes
+// with a fix to carry out by hand or, where it is safe, automatically.
+package doctor
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"slices"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// Severity ranks findings.
+type Severity int
+
+const (
+	// Warning is a problem goDrawer works around, e.g. a missing icon.
+	Warning Severity = iota
+	// Error is a problem that breaks a feature, e.g. an empty drawer.
+	Error
+)
+
+func (s Severity) String() string {
+	if s == Error {
+		return "error"
+	}
+	return "warning"
+}
+
+// Finding is a problem found by Check.
+type Finding struct {
+	Severity Severity
+	// Subject is the settings key or file concerned, e.g. drawers[1].path.
+	Subject string
+	Problem string
+	// Fix says what to do about the problem.
+	Fix string
+	// Fixed is set by Apply.
+	Fixed bool
+
+	// apply carries out Fix; it is nil when Fix needs a person.
+	apply func(*settings.Settings)
+}
+
+// Safe reports whether Apply can fix the problem on its own.
+func (f *Finding) Safe() bool {
+	return f.apply != nil
+}
+
+func (f *Finding) String() string {
+	return fmt.Sprintf("%s: %s", f.Subject, f.Problem)
+}
+
+// Options configures Check.
+type Options struct {
+	// FontPath is the brand font file. Empty skips the font check.
+	FontPath string
+}
+
+// unresolved matches what ExpandPath leaves behind when a token or variable
+// is not defined.
+var unresolved = regexp.MustCompile(`\{[A-Za-z]+\}|%[A-Za-z_][A-Za-z0-9_()]*%|\$\{?[A-Za-z_][A-Za-z0-9_]*\}?`)
+
+// Check examines s and the files it refers to.
+func Check(s *settings.Settings, opts Options) []*Finding {
+	var findings []*Finding
+	findings = append(findings, checkDrawers(s)...)
+	findings = append(findings, checkIconMap(s, "extension_icon_map", s.ExtensionIconMap, func(s *settings.Settings) map[string]string {
+		return s.ExtensionIconMap
+	})...)
+	for i, profile := range s.Profiles {
+		id := profile.ID
+		findings = append(findings, checkIconMap(s, fmt.Sprintf("profiles[%d].extension_icon_map", i), profile.ExtensionIconMap, func(s *settings.Settings) map[string]string {
+			if j := s.ProfileIndex(id); j >= 0 {
+				return s.Profiles[j].ExtensionIconMap
+			}
+			return nil
+		})...)
+	}
+	if opts.FontPath != "" {
+		if finding := checkFont(opts.FontPath); finding != nil {
+			findings = append(findings, finding)
+		}
+	}
+	findings = append(findings, checkThemes(s)...)
+	return findings
+}
+
+// Apply carries out every safe fix on s and returns how many it made.
+func Apply(s *settings.Settings, findings []*Finding) int {
+	fixed := 0
+	for _, finding := range findings {
+		if finding.apply != nil && !finding.Fixed {
+			finding.apply(s)
+			finding.Fixed = true
+			fixed++
+		}
+	}
+	return fixed
+}
+
+func checkDrawers(s *settings.Settings) []*Finding {
+	var findings []*Finding
+	for i, drawer := range s.Drawers {
+		subject := fmt.Sprintf("drawers[%d].path", i)
+		resolved := s.ResolvePath(drawer.Path)
+
+		if name := unresolved.FindString(resolved); name != "" {
+			findings = append(findings, &Finding{
+				Severity: Error,
+				Subject:  subject,
+				Problem:  fmt.Sprintf("%s in %q is not defined", name, drawer.Path),
+				Fix:      "define it, or use a known folder such as {Desktop} or a plain path",
+			})
+			continue
+		}
+
+		info, err := os.Stat(resolved)
+		switch {
+		case os.IsNotExist(err):
+			finding := &Finding{
+				Severity: Error,
+				Subject:  subject,
+				Problem:  fmt.Sprintf("folder %s does not exist", resolved),
+				Fix:      fmt.Sprintf("point %q at an existing folder, or remove it with: goDrawer drawer remove %s", drawer.Name, drawer.ID),
+			}
+			// A folder that merely looks similar may hold something else
+			// entirely, so this is only ever suggested.
+			if suggestion, ok := s.SuggestPath(drawer.Path); ok {
+				finding.Fix = fmt.Sprintf("did you mean %s? Change the folder of %q from the drawer's context menu", suggestion, drawer.Name)
+			}
+			findings = append(findings, finding)
+		case err != nil:
+			findings = append(findings, &Finding{
+				Severity: Error,
+				Subject:  subject,
+				Problem:  fmt.Sprintf("folder %s cannot be read: %v", resolved, err),
+				Fix:      "check that the drive is connected and that you may read the folder",
+			})
+		case !info.IsDir():
+			findings = append(findings, &Finding{
+				Severity: Error,
+				Subject:  subject,
+				Problem:  fmt.Sprintf("%s is a file, not a folder", resolved),
+				Fix:      fmt.Sprintf("point %q at the folder holding it", drawer.Name),
+			})
+		default:
+			if _, err := os.ReadDir(resolved); err != nil {
+				findings = append(findings, &Finding{
+					Severity: Error,
+					Subject:  subject,
+					Problem:  fmt.Sprintf("folder %s cannot be listed: %v", resolved, err),
+					Fix:      "check that you may read the folder",
+				})
+			}
+		}
+	}
+	return findings
+}
+
+// checkIconMap checks the icons of one icon map. target finds the same map
+// in the settings a fix is applied to.
+func checkIconMap(s *settings.Settings, field string, icons map[string]string, target func(*settings.Settings) map[string]string) []*Finding {
+	exts := make([]string, 0, len(icons))
+	for ext := range icons {
+		exts = append(exts, ext)
+	}
+	slices.Sort(exts)
+
+	var findings []*Finding
+	for _, ext := range exts {
+		icon := icons[ext]
+		subject := fmt.Sprintf("%s.%q", field, ext)
+		resolved := s.ResolvePath(icon)
+
+		info, err := os.Stat(resolved)
+		if err == nil && !info.IsDir() {
+			continue
+		}
+
+		finding := &Finding{
+			Severity: Warning,
+			Subject:  subject,
+			Problem:  fmt.Sprintf("icon %s not found", resolved),
+			Fix:      fmt.Sprintf("point %s at an existing image, or remove the entry to use the default icon", ext),
+		}
+		if err == nil {
+			finding.Problem = fmt.Sprintf("icon %s is a folder", resolved)
+		} else if suggestion, ok := s.SuggestPath(icon); ok {
+			ext := ext
+			finding.Fix = fmt.Sprintf("use %s", suggestion)
+			finding.apply = func(s *settings.Settings) {
+				if icons := target(s); icons != nil {
+					icons[ext] = suggestion
+				}
+			}
+		}
+		findings = append(findings, finding)
+	}
+	return findings
+}
+
+// fontMagic lists the first bytes of TrueType, OpenType and collection
+// files.
+var fontMagic = [][]byte{
+	{0x00, 0x01, 0x00, 0x00},
+	[]byte("OTTO"),
+	[]byte("true"),
+	[]byte("ttcf"),
+}
+
+func checkFont(path string) *Finding {
+	file, err := os.Open(path)
+	if err != nil {
+		return &Finding{
+			Severity: Warning,
+			Subject:  path,
+			Problem:  fmt.Sprintf("brand font cannot be opened: %v", err),
+			Fix:      "reinstall goDrawer, or copy its assets folder next to goDrawer.exe",
+		}
+	}
+	defer file.Close()
+
+	header := make([]byte, 4)
+	if _, err := io.ReadFull(file, header); err == nil {
+		for _, magic := range fontMagic {
+			if bytes.Equal(header, magic) {
+				return nil
+			}
+		}
+	}
+
+	return &Finding{
+		Severity: Warning,
+		Subject:  path,
+		Problem:  "brand font is not a TrueType or OpenType font",
+		Fix:      "replace it with the Lobster-Regular.ttf shipped with goDrawer",
+	}
+}
+
+func checkThemes(s *settings.Settings) []*Finding {
+	findings := checkTheme("theme", s.Theme, func(s *settings.Settings) *settings.Theme {
+		return &s.Theme
+	})
+	for i, profile := range s.Profiles {
+		if profile.Theme == nil {
+			continue
+		}
+		id := profile.ID
+		findings = append(findings, checkTheme(fmt.Sprintf("profiles[%d].theme", i), *profile.Theme, func(s *settings.Settings) *settings.Theme {
+			if j := s.ProfileIndex(id); j >= 0 {
+				return s.Profiles[j].Theme
+			}
+			return nil
+		})...)
+	}
+	return findings
+}
+
+// minAlpha is the opacity below which windows are hard to see.
+const minAlpha = 20
+
+func checkTheme(field string, theme settings.Theme, target func(*settings.Settings) *settings.Theme) []*Finding {
+	var findings []*Finding
+	for _, part := range []struct {
+		key   string
+		value int
+		max   int
+		get   func(*settings.Theme) *int
+	}{
+		{"h", theme.Hue, 360, func(t *settings.Theme) *int { return &t.Hue }},
+		{"s", theme.Saturation, 100, func(t *settings.Theme) *int { return &t.Saturation }},
+		{"l", theme.Lightness, 100, func(t *settings.Theme) *int { return &t.Lightness }},
+		{"a", theme.Alpha, 100, func(t *settings.Theme) *int { return &t.Alpha }},
+	} {
+		if part.value >= 0 && part.value <= part.max {
+			continue
+		}
+
+		clamped := min(max(part.value, 0), part.max)
+		get := part.get
+		findings = append(findings, &Finding{
+			Severity: Error,
+			Subject:  field + "." + part.key,
+			Problem:  fmt.Sprintf("%d is outside 0-%d", part.value, part.max),
+			Fix:      fmt.Sprintf("use %d", clamped),
+			apply: func(s *settings.Settings) {
+				if t := target(s); t != nil {
+					*get(t) = clamped
+				}
+			},
+		})
+	}
+
+	if theme.Alpha >= 0 && theme.Alpha < minAlpha {
+		findings = append(findings, &Finding{
+			Severity: Warning,
+			Subject:  field + ".a",
+			Problem:  fmt.Sprintf("opacity %d makes the windows nearly invisible", theme.Alpha),
+			Fix:      fmt.Sprintf("raise it to at least %d in the settings window", minAlpha),
+		})
+	}
+	return findings
+}
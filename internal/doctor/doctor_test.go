@@ -0,0 +1,191 @@
+package doctor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// mkfile creates the file path with contents, and its parent folders.
+func mkfile(t *testing.T, path, contents string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// findingFor returns the finding about subject, failing if there is none.
+func findingFor(t *testing.T, findings []*Finding, subject string) *Finding {
+	t.Helper()
+	for _, f := range findings {
+		if f.Subject == subject {
+			return f
+		}
+	}
+	t.Fatalf("no finding for %s in %v", subject, findings)
+	return nil
+}
+
+func TestCheckDrawers(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "docs"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	mkfile(t, filepath.Join(dir, "notes.txt"), "")
+
+	s := &settings.Settings{Theme: settings.DefaultTheme(), Drawers: []settings.Drawer{
+		{ID: "ok", Name: "Docs", Path: filepath.Join(dir, "docs")},
+		{ID: "gone", Name: "Gone", Path: filepath.Join(dir, "missing")},
+		{ID: "typo", Name: "Typo", Path: filepath.Join(dir, "docz")},
+		{ID: "file", Name: "File", Path: filepath.Join(dir, "notes.txt")},
+		{ID: "token", Name: "Token", Path: "{Nowhere}/docs"},
+	}}
+	findings := Check(s, Options{})
+	if len(findings) != 4 {
+		t.Fatalf("Check found %d problems, want 4: %v", len(findings), findings)
+	}
+
+	for _, tt := range []struct {
+		subject string
+		problem string
+		fix     string
+	}{
+		{"drawers[1].path", "does not exist", "goDrawer drawer remove gone"},
+		{"drawers[2].path", "does not exist", "did you mean"},
+		{"drawers[3].path", "is a file", "folder holding it"},
+		{"drawers[4].path", "{Nowhere}", "known folder"},
+	} {
+		f := findingFor(t, findings, tt.subject)
+		if f.Severity != Error || f.Safe() {
+			t.Errorf("%s: severity %v, safe %v; want an error to fix by hand", f, f.Severity, f.Safe())
+		}
+		if !strings.Contains(f.Problem, tt.problem) || !strings.Contains(f.Fix, tt.fix) {
+			t.Errorf("%s: fix %q, want problem with %q and fix with %q", f, f.Fix, tt.problem, tt.fix)
+		}
+	}
+}
+
+func TestCheckIconMaps(t *testing.T) {
+	dir := t.TempDir()
+	icon := filepath.Join(dir, "icons", "url.png")
+	mkfile(t, icon, "png")
+
+	s := &settings.Settings{
+		Theme: settings.DefaultTheme(),
+		ExtensionIconMap: map[string]string{
+			".url": filepath.Join(dir, "icon", "url.png"),
+			".md":  filepath.Join(dir, "icons"),
+			".txt": icon,
+		},
+		Profiles: []settings.Profile{{
+			ID:               "work",
+			Name:             "Work",
+			ExtensionIconMap: map[string]string{".lnk": filepath.Join(dir, "icons", "urll.png")},
+		}},
+	}
+	findings := Check(s, Options{})
+	if len(findings) != 3 {
+		t.Fatalf("Check found %d problems, want 3: %v", len(findings), findings)
+	}
+
+	folder := findingFor(t, findings, `extension_icon_map.".md"`)
+	if folder.Severity != Warning || folder.Safe() || !strings.Contains(folder.Problem, "is a folder") {
+		t.Errorf("%s: want a warning to fix by hand", folder)
+	}
+	for _, subject := range []string{`extension_icon_map.".url"`, `profiles[0].extension_icon_map.".lnk"`} {
+		if f := findingFor(t, findings, subject); f.Severity != Warning || !f.Safe() {
+			t.Errorf("%s: severity %v, safe %v; want a warning fixed automatically", f, f.Severity, f.Safe())
+		}
+	}
+
+	// Fixes are applied to the settings passed to Apply, which may be a
+	// copy of the checked ones.
+	fixed := s.Clone()
+	fixed.Profiles[0].ExtensionIconMap = map[string]string{".lnk": s.Profiles[0].ExtensionIconMap[".lnk"]}
+	if n := Apply(fixed, findings); n != 2 {
+		t.Errorf("Apply made %d fixes, want 2", n)
+	}
+	for _, got := range []string{fixed.ExtensionIconMap[".url"], fixed.Profiles[0].ExtensionIconMap[".lnk"]} {
+		if resolved := fixed.ResolvePath(got); resolved != icon {
+			t.Errorf("fixed icon = %q (%s), want %s", got, resolved, icon)
+		}
+	}
+	if s.ExtensionIconMap[".url"] != filepath.Join(dir, "icon", "url.png") {
+		t.Errorf("Apply changed the checked settings: %q", s.ExtensionIconMap[".url"])
+	}
+	if n := Apply(fixed, findings); n != 0 {
+		t.Errorf("second Apply made %d fixes, want 0", n)
+	}
+}
+
+func TestCheckFont(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.ttf")
+	mkfile(t, valid, "\x00\x01\x00\x00rest")
+	invalid := filepath.Join(dir, "invalid.ttf")
+	mkfile(t, invalid, "<html>")
+
+	tests := []struct {
+		path    string
+		problem string
+	}{
+		{valid, ""},
+		{invalid, "not a TrueType or OpenType font"},
+		{filepath.Join(dir, "missing.ttf"), "cannot be opened"},
+	}
+	for _, tt := range tests {
+		findings := Check(&settings.Settings{Theme: settings.DefaultTheme()}, Options{FontPath: tt.path})
+		if tt.problem == "" {
+			if len(findings) != 0 {
+				t.Errorf("%s: findings %v, want none", tt.path, findings)
+			}
+			continue
+		}
+		f := findingFor(t, findings, tt.path)
+		if f.Severity != Warning || f.Safe() || !strings.Contains(f.Problem, tt.problem) {
+			t.Errorf("%s: got %s, want a warning about %q", tt.path, f, tt.problem)
+		}
+	}
+}
+
+func TestCheckThemes(t *testing.T) {
+	theme := settings.DefaultTheme()
+	theme.Hue = 400
+	theme.Alpha = -5
+	faint := settings.DefaultTheme()
+	faint.Alpha = 5
+
+	s := &settings.Settings{
+		Theme:    theme,
+		Profiles: []settings.Profile{{ID: "work", Name: "Work", Theme: &faint}},
+	}
+	findings := Check(s, Options{})
+	if len(findings) != 3 {
+		t.Fatalf("Check found %d problems, want 3: %v", len(findings), findings)
+	}
+
+	for _, subject := range []string{"theme.h", "theme.a"} {
+		if f := findingFor(t, findings, subject); f.Severity != Error || !f.Safe() {
+			t.Errorf("%s: severity %v, safe %v; want an error fixed automatically", f, f.Severity, f.Safe())
+		}
+	}
+	if f := findingFor(t, findings, "profiles[0].theme.a"); f.Severity != Warning || f.Safe() {
+		t.Errorf("%s: severity %v, safe %v; want a warning to fix by hand", f, f.Severity, f.Safe())
+	}
+
+	if n := Apply(s, findings); n != 2 {
+		t.Errorf("Apply made %d fixes, want 2", n)
+	}
+	if s.Theme.Hue != 360 || s.Theme.Alpha != 0 {
+		t.Errorf("fixed theme = %+v, want h 360 and a 0", s.Theme)
+	}
+	if err := settings.ValidateTheme(s.Theme); err != nil {
+		t.Errorf("fixed theme is still invalid: %v", err)
+	}
+}
@@ -0,0 +1,257 @@
+// Package instance keeps goDrawer to one running process per settings file.
+// The first process claims a lock file next to the settings file and
+// listens on a loopback port recorded in it; later launches forward their
+// command line there and exit.
+package instance
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrRunning is returned by Claim when another process owns the lock.
+var ErrRunning = errors.New("goDrawer is already running")
+
+// dialTimeout bounds how long a launch waits for the running instance.
+const dialTimeout = 2 * time.Second
+
+// LockPath returns the lock file for the settings file at path:
+// goDrawer-settings.toml has goDrawer-settings.instance.
+func LockPath(path string) string {
+	return strings.TrimSuffix(path, filepath.Ext(path)) + ".instance"
+}
+
+// message is what a later launch sends to the running instance, one JSON
+// object per connection.
+type message struct {
+	Token string   `json:"token"`
+	Args  []string `json:"args"`
+	// Ping only checks that the instance is alive.
+	Ping bool `json:"ping,omitempty"`
+}
+
+// Server is the running instance's end of the channel.
+type Server struct {
+	path     string
+	token    string
+	listener net.Listener
+
+	mu     sync.Mutex
+	handle func(args []string)
+	// queue holds the arguments received before Serve was called.
+	queue [][]string
+}
+
+// Claim makes this process the running instance for the lock file at path
+// and starts answering later launches right away; their arguments are
+// queued until Serve is called. It returns ErrRunning when a live process
+// holds the lock already; a lock left behind by a process that died is
+// taken over.
+func Claim(path string) (*Server, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o600)
+		if errors.Is(err, os.ErrExist) {
+			if alive(path) {
+				return nil, ErrRunning
+			}
+			if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+				return nil, fmt.Errorf("failed to remove stale instance lock: %w", err)
+			}
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to create instance lock: %w", err)
+		}
+
+		server, err := listen(path, file)
+		if err != nil {
+			os.Remove(path)
+			return nil, err
+		}
+		return server, nil
+	}
+	return nil, ErrRunning
+}
+
+func listen(path string, file *os.File) (*Server, error) {
+	defer file.Close()
+
+	token, err := newToken()
+	if err != nil {
+		return nil, err
+	}
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, fmt.Errorf("failed to listen for other instances: %w", err)
+	}
+
+	if _, err := fmt.Fprintf(file, "%s\n%s\n%d\n", listener.Addr(), token, os.Getpid()); err != nil {
+		listener.Close()
+		return nil, fmt.Errorf("failed to write instance lock: %w", err)
+	}
+
+	s := &Server{path: path, token: token, listener: listener}
+	go s.accept()
+	return s, nil
+}
+
+func newToken() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate instance token: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Serve calls handle with the arguments of every later launch until Close
+// is called, starting with those queued since Claim. handle runs on the
+// serving goroutine, or on the caller's for queued launches.
+func (s *Server) Serve(handle func(args []string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, args := range s.queue {
+		handle(args)
+	}
+	s.queue = nil
+	s.handle = handle
+}
+
+func (s *Server) accept() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("instance listener stopped: %v", err)
+			}
+			return
+		}
+		s.receive(conn)
+	}
+}
+
+func (s *Server) receive(conn net.Conn) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	var msg message
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&msg); err != nil {
+		if !errors.Is(err, io.EOF) {
+			log.Printf("ignoring malformed instance message: %v", err)
+		}
+		return
+	}
+	if msg.Token != s.token {
+		log.Printf("ignoring instance message with a wrong token")
+		return
+	}
+
+	fmt.Fprintln(conn, "ok")
+	if !msg.Ping {
+		s.dispatch(msg.Args)
+	}
+}
+
+// dispatch hands args to the handler, or queues them until there is one.
+func (s *Server) dispatch(args []string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.handle == nil {
+		s.queue = append(s.queue, args)
+		return
+	}
+	s.handle(args)
+}
+
+// Close stops listening and releases the lock.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+	if removeErr := os.Remove(s.path); removeErr != nil && !os.IsNotExist(removeErr) && err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// Forward sends args to the instance holding the lock at path and waits
+// until it has received them.
+func Forward(path string, args []string) error {
+	if args == nil {
+		args = []string{}
+	}
+	return send(path, message{Args: args})
+}
+
+func send(path string, msg message) error {
+	addr, token, err := readLock(path)
+	if err != nil {
+		return err
+	}
+	msg.Token = token
+
+	conn, err := net.DialTimeout("tcp", addr, dialTimeout)
+	if err != nil {
+		return fmt.Errorf("failed to reach the running instance: %w", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(dialTimeout))
+
+	if err := json.NewEncoder(conn).Encode(msg); err != nil {
+		return fmt.Errorf("failed to send arguments to the running instance: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString('\n')
+	if err != nil {
+		return fmt.Errorf("the running instance did not answer: %w", err)
+	}
+	if strings.TrimSpace(reply) != "ok" {
+		return fmt.Errorf("the running instance did not accept the arguments")
+	}
+	return nil
+}
+
+// readLock returns the address and token from the lock file. The owner
+// writes them right after creating it, so an empty file is retried
+// briefly.
+func readLock(path string) (addr, token string, err error) {
+	deadline := time.Now().Add(dialTimeout)
+	for {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", "", fmt.Errorf("failed to read instance lock: %w", err)
+		}
+
+		lines := strings.Split(string(data), "\n")
+		if len(lines) >= 2 && lines[0] != "" && lines[1] != "" {
+			return strings.TrimSpace(lines[0]), strings.TrimSpace(lines[1]), nil
+		}
+		if time.Now().After(deadline) {
+			return "", "", fmt.Errorf("instance lock %s is incomplete", path)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// alive reports whether the lock at path belongs to a process that still
+// runs. The token check keeps an unrelated program that took over the
+// port of a dead instance from counting. An instance too busy to answer
+// in time counts as alive; only a refused or rejected ping marks the lock
+// stale.
+func alive(path string) bool {
+	err := send(path, message{Ping: true})
+	var netErr net.Error
+	return err == nil || errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,153 @@
+package instance
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func lockPath(t *testing.T) string {
+	t.Helper()
+	return LockPath(filepath.Join(t.TempDir(), "goDrawer-settings.toml"))
+}
+
+func claim(t *testing.T, path string) *Server {
+	t.Helper()
+	server, err := Claim(path)
+	if err != nil {
+		t.Fatalf("Claim = %v", err)
+	}
+	t.Cleanup(func() { server.Close() })
+	return server
+}
+
+func TestLockPath(t *testing.T) {
+	got := LockPath(filepath.Join("dir", "goDrawer-settings.toml"))
+	if want := filepath.Join("dir", "goDrawer-settings.instance"); got != want {
+		t.Errorf("LockPath = %q, want %q", got, want)
+	}
+}
+
+func TestSecondInstanceForwards(t *testing.T) {
+	path := lockPath(t)
+	server := claim(t, path)
+
+	if _, err := Claim(path); !errors.Is(err, ErrRunning) {
+		t.Fatalf("second Claim = %v, want ErrRunning", err)
+	}
+
+	// Launches before Serve are queued and handed over in order.
+	if err := Forward(path, []string{"--open", "Docs"}); err != nil {
+		t.Fatalf("Forward before Serve = %v", err)
+	}
+	if err := Forward(path, nil); err != nil {
+		t.Fatalf("Forward without arguments = %v", err)
+	}
+
+	received := make(chan []string, 4)
+	server.Serve(func(args []string) { received <- args })
+
+	if err := Forward(path, []string{"--reload"}); err != nil {
+		t.Fatalf("Forward after Serve = %v", err)
+	}
+
+	for _, want := range [][]string{{"--open", "Docs"}, {}, {"--reload"}} {
+		select {
+		case got := <-received:
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("received %q, want %q", got, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%q was not received", want)
+		}
+	}
+}
+
+func TestClaimTakesOverStaleLock(t *testing.T) {
+	path := lockPath(t)
+
+	// The port of a listener that is gone refuses connections, as the
+	// port of a process that died does.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := listener.Addr().String()
+	listener.Close()
+
+	stale := fmt.Sprintf("%s\n%s\n%d\n", addr, "deadbeef", 1)
+	if err := os.WriteFile(path, []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	server := claim(t, path)
+	if err := Forward(path, []string{"--show"}); err != nil {
+		t.Fatalf("Forward to the new instance = %v", err)
+	}
+
+	if err := server.Close(); err != nil {
+		t.Fatalf("Close = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("lock remains after Close: %v", err)
+	}
+}
+
+func TestClaimTakesOverLockOfOtherProgram(t *testing.T) {
+	path := lockPath(t)
+
+	// Something that is not goDrawer listens on the recorded port and
+	// hangs up without answering.
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer listener.Close()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+
+	stale := fmt.Sprintf("%s\n%s\n%d\n", listener.Addr(), "deadbeef", 1)
+	if err := os.WriteFile(path, []byte(stale), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	claim(t, path)
+}
+
+func TestWrongTokenIsRejected(t *testing.T) {
+	path := lockPath(t)
+	server := claim(t, path)
+
+	received := make(chan []string, 1)
+	server.Serve(func(args []string) { received <- args })
+
+	addr, _, err := readLock(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	forged := LockPath(filepath.Join(t.TempDir(), "forged.toml"))
+	if err := os.WriteFile(forged, []byte(addr+"\nwrong\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := Forward(forged, []string{"--show"}); err == nil {
+		t.Error("Forward with a wrong token succeeded")
+	}
+	select {
+	case args := <-received:
+		t.Errorf("received %q with a wrong token", args)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
@@ -0,0 +1,110 @@
+// Package platform puts the operating system services goDrawer relies on
+// behind interfaces: window styling, opening files, font registration,
+// autostart, the tray icon and folder change notifications. The Windows
+// implementations are the real ones; elsewhere most services report
+// errors.ErrUnsupported, which lets the platform-independent packages
+// build and be tested on any host.
+package platform
+
+// Handle identifies a native window, e.g. an HWND on Windows.
+type Handle uintptr
+
+// Windows styles and moves top-level windows.
+type Windows interface {
+	// MakeBorderless removes the caption and frame of a window.
+	MakeBorderless(h Handle) error
+	// SetOpacity makes a window translucent; 255 is opaque.
+	SetOpacity(h Handle, alpha byte) error
+	// HideFromTaskbar keeps a window out of the taskbar and task switcher.
+	HideFromTaskbar(h Handle) error
+	// BeginDrag lets the user move a window while the left mouse button
+	// is held down.
+	BeginDrag(h Handle)
+	// CursorPos returns the mouse position in the client coordinates of a
+	// window.
+	CursorPos(h Handle) (x, y int, ok bool)
+}
+
+// Shell hands files to the programs the user associated with them.
+type Shell interface {
+	// Open opens path as if it was double-clicked in the file manager.
+	Open(path string) error
+}
+
+// Fonts makes font files available to the process.
+type Fonts interface {
+	// Register loads the font file at path for this process only.
+	Register(path string) error
+}
+
+// Autostart controls whether goDrawer starts when the user logs in.
+type Autostart interface {
+	Enabled() (bool, error)
+	// Enable starts the current executable with args at login.
+	Enable(args []string) error
+	Disable() error
+}
+
+// Trays creates tray icons.
+type Trays interface {
+	NewTray(opts TrayOptions) (Tray, error)
+}
+
+// TrayOptions configures a tray icon.
+type TrayOptions struct {
+	// Icon is the path of the icon file. An icon that cannot be loaded is
+	// left out.
+	Icon    string
+	ToolTip string
+	// OnClick is called when the icon is clicked with the left button.
+	OnClick func()
+}
+
+// Tray is an icon in the notification area with a context menu.
+type Tray interface {
+	// SetMenu replaces the context menu.
+	SetMenu(items []MenuItem) error
+	Close() error
+}
+
+// MenuItem is an entry of a tray menu. An item without text is a
+// separator; an item with Items opens a submenu.
+type MenuItem struct {
+	Text      string
+	Disabled  bool
+	Checkable bool
+	Checked   bool
+	Items     []MenuItem
+	OnClick   func()
+}
+
+// Separator is a line between groups of menu items.
+var Separator = MenuItem{}
+
+// Watchers reports changes to folders.
+type Watchers interface {
+	// Watch calls onChange, on a goroutine of its own, whenever entries of
+	// the folder dir are added, removed, renamed or modified. Changes come
+	// in bursts; onChange may be called several times for one of them.
+	// When the watch ends on its own, e.g. because dir was deleted or
+	// renamed, onFail is called on that goroutine and nothing after it.
+	Watch(dir string, onChange func(), onFail func(error)) (Watcher, error)
+}
+
+// Watcher is a folder being watched.
+type Watcher interface {
+	// Close stops the notifications. It need not wait for a call of
+	// onChange in progress, so callers must ignore calls that arrive
+	// after Close.
+	Close() error
+}
+
+// Services bundles the platform services.
+type Services struct {
+	Windows   Windows
+	Shell     Shell
+	Fonts     Fonts
+	Autostart Autostart
+	Trays     Trays
+	Watchers  Watchers
+}
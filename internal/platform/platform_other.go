@@ -0,0 +1,66 @@
+//go:build !windows
+
+package platform
+
+import (
+	"errors"
+	"os/exec"
+	"runtime"
+)
+
+// Native returns the services of the host. Only Shell is implemented
+// outside Windows.
+func Native() Services {
+	return Services{
+		Windows:   unsupportedWindows{},
+		Shell:     openerShell{},
+		Fonts:     unsupportedFonts{},
+		Autostart: unsupportedAutostart{},
+		Trays:     unsupportedTrays{},
+		Watchers:  unsupportedWatchers{},
+	}
+}
+
+type unsupportedWindows struct{}
+
+func (unsupportedWindows) MakeBorderless(Handle) error       { return errors.ErrUnsupported }
+func (unsupportedWindows) SetOpacity(Handle, byte) error     { return errors.ErrUnsupported }
+func (unsupportedWindows) HideFromTaskbar(Handle) error      { return errors.ErrUnsupported }
+func (unsupportedWindows) BeginDrag(Handle)                  {}
+func (unsupportedWindows) CursorPos(Handle) (int, int, bool) { return 0, 0, false }
+
+// openerShell opens files with xdg-open, or open on macOS.
+type openerShell struct{}
+
+func (openerShell) Open(path string) error {
+	opener := "xdg-open"
+	if runtime.GOOS == "darwin" {
+		opener = "open"
+	}
+	cmd := exec.Command(opener, path)
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	go cmd.Wait()
+	return nil
+}
+
+type unsupportedFonts struct{}
+
+func (unsupportedFonts) Register(string) error { return errors.ErrUnsupported }
+
+type unsupportedAutostart struct{}
+
+func (unsupportedAutostart) Enabled() (bool, error) { return false, errors.ErrUnsupported }
+func (unsupportedAutostart) Enable([]string) error  { return errors.ErrUnsupported }
+func (unsupportedAutostart) Disable() error         { return errors.ErrUnsupported }
+
+type unsupportedTrays struct{}
+
+func (unsupportedTrays) NewTray(TrayOptions) (Tray, error) { return nil, errors.ErrUnsupported }
+
+type unsupportedWatchers struct{}
+
+func (unsupportedWatchers) Watch(string, func(), func(error)) (Watcher, error) {
+	return nil, errors.ErrUnsupported
+}
@@ -0,0 +1,132 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/windows/registry"
+
+	"github.com/lxn/win"
+)
+
+// Native returns the services of the host.
+func Native() Services {
+	return Services{
+		Windows:   &win32Windows{},
+		Shell:     win32Shell{},
+		Fonts:     win32Fonts{},
+		Autostart: runKeyAutostart{name: "goDrawer"},
+		Trays:     walkTrays{},
+		Watchers:  changeWatchers{},
+	}
+}
+
+// win32Shell opens files with ShellExecute.
+type win32Shell struct{}
+
+func (win32Shell) Open(path string) error {
+	filePtr, err := syscall.UTF16PtrFromString(path)
+	if err != nil {
+		return err
+	}
+	if !win.ShellExecute(0, nil, filePtr, nil, nil, win.SW_SHOWNORMAL) {
+		return fmt.Errorf("shell execute failed for %s", path)
+	}
+	return nil
+}
+
+const frPrivate = 0x10
+
+var (
+	gdi32                 = syscall.NewLazyDLL("gdi32.dll")
+	procAddFontResourceEx = gdi32.NewProc("AddFontResourceExW")
+)
+
+// win32Fonts registers private fonts with AddFontResourceEx.
+type win32Fonts struct{}
+
+func (win32Fonts) Register(path string) error {
+	absPath, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+
+	ptr, err := syscall.UTF16PtrFromString(absPath)
+	if err != nil {
+		return err
+	}
+
+	r, _, callErr := procAddFontResourceEx.Call(
+		uintptr(unsafe.Pointer(ptr)),
+		frPrivate,
+		0,
+	)
+	if r == 0 {
+		return lastError(callErr)
+	}
+	return nil
+}
+
+const runKey = `Software\Microsoft\Windows\CurrentVersion\Run`
+
+// runKeyAutostart starts goDrawer through a value of the user's Run key.
+type runKeyAutostart struct {
+	name string
+}
+
+func (a runKeyAutostart) Enabled() (bool, error) {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKey, registry.QUERY_VALUE)
+	if err != nil {
+		return false, fmt.Errorf("failed to open Run key: %w", err)
+	}
+	defer key.Close()
+
+	if _, _, err := key.GetStringValue(a.name); err != nil {
+		if errors.Is(err, registry.ErrNotExist) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to read Run key: %w", err)
+	}
+	return true, nil
+}
+
+func (a runKeyAutostart) Enable(args []string) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("failed to locate executable: %w", err)
+	}
+
+	command := []string{syscall.EscapeArg(exe)}
+	for _, arg := range args {
+		command = append(command, syscall.EscapeArg(arg))
+	}
+
+	key, _, err := registry.CreateKey(registry.CURRENT_USER, runKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open Run key: %w", err)
+	}
+	defer key.Close()
+
+	if err := key.SetStringValue(a.name, strings.Join(command, " ")); err != nil {
+		return fmt.Errorf("failed to write Run key: %w", err)
+	}
+	return nil
+}
+
+func (a runKeyAutostart) Disable() error {
+	key, err := registry.OpenKey(registry.CURRENT_USER, runKey, registry.SET_VALUE)
+	if err != nil {
+		return fmt.Errorf("failed to open Run key: %w", err)
+	}
+	defer key.Close()
+
+	if err := key.DeleteValue(a.name); err != nil && !errors.Is(err, registry.ErrNotExist) {
+		return fmt.Errorf("failed to write Run key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package platform
+
+import (
+	"log"
+
+	"github.com/lxn/walk"
+)
+
+// walkTrays creates tray icons with walk. Each icon gets a hidden window of
+// its own to receive its messages, so it must be created on the UI thread.
+type walkTrays struct{}
+
+func (walkTrays) NewTray(opts TrayOptions) (Tray, error) {
+	owner, err := walk.NewMainWindow()
+	if err != nil {
+		return nil, err
+	}
+
+	ni, err := walk.NewNotifyIcon(owner)
+	if err != nil {
+		owner.Dispose()
+		return nil, err
+	}
+
+	t := &walkTray{owner: owner, icon: ni}
+	if opts.Icon != "" {
+		if icon, err := walk.NewIconFromFile(opts.Icon); err == nil {
+			t.image = icon
+			ni.SetIcon(icon)
+		} else {
+			log.Printf("warn: failed to load tray icon: %v", err)
+		}
+	}
+	ni.SetToolTip(opts.ToolTip)
+
+	if opts.OnClick != nil {
+		ni.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+			if button == walk.LeftButton {
+				opts.OnClick()
+			}
+		})
+	}
+
+	if err := ni.SetVisible(true); err != nil {
+		t.Close()
+		return nil, err
+	}
+	return t, nil
+}
+
+type walkTray struct {
+	owner *walk.MainWindow
+	icon  *walk.NotifyIcon
+	image *walk.Icon
+}
+
+func (t *walkTray) SetMenu(items []MenuItem) error {
+	actions := t.icon.ContextMenu().Actions()
+	if err := actions.Clear(); err != nil {
+		return err
+	}
+	return addMenuItems(actions, items)
+}
+
+func addMenuItems(actions *walk.ActionList, items []MenuItem) error {
+	for _, item := range items {
+		action, err := newMenuAction(item)
+		if err != nil {
+			return err
+		}
+		if err := actions.Add(action); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func newMenuAction(item MenuItem) (*walk.Action, error) {
+	if item.Text == "" {
+		return walk.NewSeparatorAction(), nil
+	}
+
+	var action *walk.Action
+	if len(item.Items) > 0 {
+		menu, err := walk.NewMenu()
+		if err != nil {
+			return nil, err
+		}
+		if err := addMenuItems(menu.Actions(), item.Items); err != nil {
+			return nil, err
+		}
+		action = walk.NewMenuAction(menu)
+	} else {
+		action = walk.NewAction()
+	}
+
+	action.SetText(item.Text)
+	action.SetEnabled(!item.Disabled)
+	if item.Checkable {
+		action.SetCheckable(true)
+		action.SetChecked(item.Checked)
+	}
+	if item.OnClick != nil {
+		action.Triggered().Attach(item.OnClick)
+	}
+	return action, nil
+}
+
+func (t *walkTray) Close() error {
+	err := t.icon.Dispose()
+	if t.image != nil {
+		t.image.Dispose()
+	}
+	t.owner.Dispose()
+	return err
+}
@@ -0,0 +1,80 @@
+package platform
+
+import (
+	"fmt"
+	"sync"
+
+	"golang.org/x/sys/windows"
+)
+
+const changeFilter = windows.FILE_NOTIFY_CHANGE_FILE_NAME |
+	windows.FILE_NOTIFY_CHANGE_DIR_NAME |
+	windows.FILE_NOTIFY_CHANGE_ATTRIBUTES |
+	windows.FILE_NOTIFY_CHANGE_SIZE |
+	windows.FILE_NOTIFY_CHANGE_LAST_WRITE
+
+// changeWatchers watches folders with change notification handles.
+type changeWatchers struct{}
+
+func (changeWatchers) Watch(dir string, onChange func(), onFail func(error)) (Watcher, error) {
+	change, err := windows.FindFirstChangeNotification(dir, false, changeFilter)
+	if err != nil {
+		return nil, fmt.Errorf("failed to watch %s: %w", dir, err)
+	}
+	stop, err := windows.CreateEvent(nil, 1, 0, nil)
+	if err != nil {
+		windows.FindCloseChangeNotification(change)
+		return nil, fmt.Errorf("failed to create stop event: %w", err)
+	}
+
+	w := &changeWatcher{dir: dir, change: change, stop: stop, done: make(chan struct{})}
+	go w.loop(onChange, onFail)
+	return w, nil
+}
+
+type changeWatcher struct {
+	dir    string
+	change windows.Handle
+	stop   windows.Handle
+	done   chan struct{}
+	once   sync.Once
+}
+
+func (w *changeWatcher) loop(onChange func(), onFail func(error)) {
+	defer close(w.done)
+
+	handles := []windows.Handle{w.change, w.stop}
+	for {
+		event, err := windows.WaitForMultipleObjects(handles, false, windows.INFINITE)
+		switch {
+		case err != nil:
+			onFail(fmt.Errorf("failed to wait for changes to %s: %w", w.dir, err))
+			return
+		case event == windows.WAIT_OBJECT_0+1:
+			return
+		case event != windows.WAIT_OBJECT_0:
+			onFail(fmt.Errorf("watch of %s ended unexpectedly", w.dir))
+			return
+		}
+
+		onChange()
+		// The handle stops working when the folder is deleted or renamed.
+		if err := windows.FindNextChangeNotification(w.change); err != nil {
+			onFail(fmt.Errorf("failed to keep watching %s: %w", w.dir, err))
+			return
+		}
+	}
+}
+
+func (w *changeWatcher) Close() error {
+	var err error
+	w.once.Do(func() {
+		if err = windows.SetEvent(w.stop); err != nil {
+			return
+		}
+		<-w.done
+		err = windows.FindCloseChangeNotification(w.change)
+		windows.CloseHandle(w.stop)
+	})
+	return err
+}
@@ -1,10 +1,9 @@
-package ui
+package platform
 
 import (
 	"syscall"
 	"unsafe"
 
-	"github.com/lxn/walk"
 	"github.com/lxn/win"
 )
 
@@ -18,11 +17,16 @@ var (
 	user32                         = syscall.NewLazyDLL("user32.dll")
 	procSetLayeredWindowAttributes = user32.NewProc("SetLayeredWindowAttributes")
 	procCreateWindowEx             = user32.NewProc("CreateWindowExW")
-	hwndOwner                      win.HWND
 )
 
-func makeWindowBorderless(mw *walk.MainWindow) error {
-	hwnd := mw.Handle()
+// win32Windows styles windows through user32. Windows hidden from the
+// taskbar are owned by a message-only window it creates on first use.
+type win32Windows struct {
+	owner win.HWND
+}
+
+func (w *win32Windows) MakeBorderless(h Handle) error {
+	hwnd := win.HWND(h)
 	if hwnd == 0 {
 		return syscall.EINVAL
 	}
@@ -31,22 +35,14 @@ func makeWindowBorderless(mw *walk.MainWindow) error {
 	style &^= uint32(win.WS_CAPTION | win.WS_THICKFRAME | win.WS_MINIMIZE | win.WS_MAXIMIZE | win.WS_SYSMENU)
 	style |= uint32(win.WS_POPUP)
 
-	win.SetLastError(0)
-	if prev := win.SetWindowLong(hwnd, win.GWL_STYLE, int32(style)); prev == 0 {
-		if err := syscall.GetLastError(); err != nil && err != syscall.Errno(0) {
-			return err
-		}
-	}
-
-	if !win.SetWindowPos(hwnd, 0, 0, 0, 0, 0, win.SWP_NOMOVE|win.SWP_NOSIZE|win.SWP_NOZORDER|win.SWP_FRAMECHANGED|win.SWP_NOACTIVATE) {
-		return syscall.GetLastError()
+	if err := setWindowLongWithError(hwnd, win.GWL_STYLE, int32(style)); err != nil {
+		return err
 	}
-
-	return nil
+	return refreshFrame(hwnd)
 }
 
-func makeWindowSemiTransparent(mw *walk.MainWindow, alpha byte) error {
-	hwnd := mw.Handle()
+func (w *win32Windows) SetOpacity(h Handle, alpha byte) error {
+	hwnd := win.HWND(h)
 	if hwnd == 0 {
 		return syscall.EINVAL
 	}
@@ -54,51 +50,29 @@ func makeWindowSemiTransparent(mw *walk.MainWindow, alpha byte) error {
 	exStyle := win.GetWindowLong(hwnd, win.GWL_EXSTYLE)
 	win.SetWindowLong(hwnd, win.GWL_EXSTYLE, exStyle|win.WS_EX_LAYERED)
 
-	return setLayeredWindowAttributes(hwnd, win.COLORREF(0), alpha, lwaAlpha)
-}
-
-func setLayeredWindowAttributes(hwnd win.HWND, key win.COLORREF, alpha byte, flags uint32) error {
 	r1, _, err := procSetLayeredWindowAttributes.Call(
 		uintptr(hwnd),
-		uintptr(key),
+		uintptr(win.COLORREF(0)),
 		uintptr(alpha),
-		uintptr(flags),
+		uintptr(lwaAlpha),
 	)
 	if r1 == 0 {
-		if errno, ok := err.(syscall.Errno); ok && errno != 0 {
-			return errno
-		}
-		return syscall.EINVAL
+		return lastError(err)
 	}
-
 	return nil
 }
 
-func beginWindowDrag(mw *walk.MainWindow) {
-	hwnd := mw.Handle()
-	if hwnd == 0 {
-		return
-	}
-
-	win.ReleaseCapture()
-	win.SendMessage(hwnd, win.WM_NCLBUTTONDOWN, uintptr(win.HTCAPTION), 0)
-}
-
-func hideFromTaskbar(mw *walk.MainWindow) error {
-	if mw == nil {
-		return syscall.EINVAL
-	}
-
-	hwnd := win.HWND(mw.Handle())
+func (w *win32Windows) HideFromTaskbar(h Handle) error {
+	hwnd := win.HWND(h)
 	if hwnd == 0 {
 		return syscall.EINVAL
 	}
 
-	if err := ensureOwnerWindow(); err != nil {
+	if err := w.ensureOwner(); err != nil {
 		return err
 	}
 
-	if err := setWindowLongPtrWithError(hwnd, win.GWL_HWNDPARENT, uintptr(hwndOwner)); err != nil {
+	if err := setWindowLongPtrWithError(hwnd, win.GWL_HWNDPARENT, uintptr(w.owner)); err != nil {
 		return err
 	}
 
@@ -109,16 +83,32 @@ func hideFromTaskbar(mw *walk.MainWindow) error {
 	if err := setWindowLongWithError(hwnd, win.GWL_EXSTYLE, int32(exStyle)); err != nil {
 		return err
 	}
+	return refreshFrame(hwnd)
+}
 
-	if !win.SetWindowPos(hwnd, 0, 0, 0, 0, 0, win.SWP_NOMOVE|win.SWP_NOSIZE|win.SWP_NOZORDER|win.SWP_FRAMECHANGED|win.SWP_NOACTIVATE) {
-		return syscall.GetLastError()
+func (w *win32Windows) BeginDrag(h Handle) {
+	hwnd := win.HWND(h)
+	if hwnd == 0 {
+		return
 	}
 
-	return nil
+	win.ReleaseCapture()
+	win.SendMessage(hwnd, win.WM_NCLBUTTONDOWN, uintptr(win.HTCAPTION), 0)
+}
+
+func (w *win32Windows) CursorPos(h Handle) (int, int, bool) {
+	var pt win.POINT
+	if !win.GetCursorPos(&pt) {
+		return 0, 0, false
+	}
+	if !win.ScreenToClient(win.HWND(h), &pt) {
+		return 0, 0, false
+	}
+	return int(pt.X), int(pt.Y), true
 }
 
-func ensureOwnerWindow() error {
-	if hwndOwner != 0 {
+func (w *win32Windows) ensureOwner() error {
+	if w.owner != 0 {
 		return nil
 	}
 
@@ -136,13 +126,17 @@ func ensureOwnerWindow() error {
 		0,
 	)
 	if r == 0 {
-		if errno, ok := err.(syscall.Errno); ok && errno != 0 {
-			return errno
-		}
-		return syscall.EINVAL
+		return lastError(err)
 	}
 
-	hwndOwner = win.HWND(r)
+	w.owner = win.HWND(r)
+	return nil
+}
+
+func refreshFrame(hwnd win.HWND) error {
+	if !win.SetWindowPos(hwnd, 0, 0, 0, 0, 0, win.SWP_NOMOVE|win.SWP_NOSIZE|win.SWP_NOZORDER|win.SWP_FRAMECHANGED|win.SWP_NOACTIVATE) {
+		return syscall.GetLastError()
+	}
 	return nil
 }
 
@@ -161,9 +155,18 @@ func setWindowLongWithError(hwnd win.HWND, index int32, value int32) error {
 	win.SetLastError(0)
 	prev := win.SetWindowLong(hwnd, index, value)
 	if prev == 0 {
-		if err := syscall.GetLastError(); err != syscall.Errno(0) {
+		if err := syscall.GetLastError(); err != nil && err != syscall.Errno(0) {
 			return err
 		}
 	}
 	return nil
 }
+
+// lastError turns the error of a failed LazyProc call into the Errno it
+// carries.
+func lastError(err error) error {
+	if errno, ok := err.(syscall.Errno); ok && errno != 0 {
+		return errno
+	}
+	return syscall.EINVAL
+}
@@ -0,0 +1,37 @@
+package settings
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// DefaultControlPort is the loopback port of the control API when the
+// settings do not name one.
+const DefaultControlPort = 47813
+
+// Control configures the local control API used by scripts and other
+// tools. It is off unless enabled and only ever listens on loopback.
+type Control struct {
+	Enabled bool `toml:"enabled"`
+	Port    int  `toml:"port,omitzero"`
+	// Token must be sent as a bearer token with every request. An enabled
+	// API without one gets a random token on startup.
+	Token string `toml:"token,omitempty"`
+}
+
+// PortOrDefault returns the configured port, or DefaultControlPort.
+func (c Control) PortOrDefault() int {
+	if c.Port == 0 {
+		return DefaultControlPort
+	}
+	return c.Port
+}
+
+// NewControlToken returns a fresh random control API token.
+func NewControlToken() string {
+	var buf [24]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic("settings: failed to generate token: " + err.Error())
+	}
+	return hex.EncodeToString(buf[:])
+}
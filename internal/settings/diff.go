@@ -0,0 +1,138 @@
+package settings
+
+import (
+	"maps"
+	"reflect"
+	"slices"
+	"strings"
+)
+
+// Change is a bit set naming the sections that differ between two Settings.
+type Change uint
+
+const (
+	ChangeStartup Change = 1 << iota
+	ChangeDrawers
+	ChangeGroups
+	ChangeWindowPosition
+	ChangeThumbnailSize
+	ChangeTheme
+	ChangeExtensionIconMap
+	ChangeProfiles
+	ChangeControl
+)
+
+var changeNames = []struct {
+	change Change
+	name   string
+}{
+	{ChangeStartup, "startup"},
+	{ChangeDrawers, "drawers"},
+	{ChangeGroups, "groups"},
+	{ChangeWindowPosition, "window_position"},
+	{ChangeThumbnailSize, "thumbnail_size"},
+	{ChangeTheme, "theme"},
+	{ChangeExtensionIconMap, "extension_icon_map"},
+	{ChangeProfiles, "profiles"},
+	{ChangeControl, "control"},
+}
+
+// Has reports whether every bit of other is set in c.
+func (c Change) Has(other Change) bool {
+	return c&other == other
+}
+
+func (c Change) String() string {
+	if c == 0 {
+		return "none"
+	}
+
+	var names []string
+	for _, entry := range changeNames {
+		if c.Has(entry.change) {
+			names = append(names, entry.name)
+		}
+	}
+	return strings.Join(names, ", ")
+}
+
+// Diff reports which sections differ between a and b.
+func Diff(a, b *Settings) Change {
+	var change Change
+
+	if a.Startup != b.Startup {
+		change |= ChangeStartup
+	}
+	if !reflect.DeepEqual(a.Drawers, b.Drawers) {
+		change |= ChangeDrawers
+	}
+	if !slices.Equal(a.Groups, b.Groups) {
+		change |= ChangeGroups
+	}
+	if a.WindowPosition != b.WindowPosition {
+		change |= ChangeWindowPosition
+	}
+	if a.ThumbnailSize != b.ThumbnailSize {
+		change |= ChangeThumbnailSize
+	}
+	if a.Theme != b.Theme {
+		change |= ChangeTheme
+	}
+	if !maps.Equal(a.ExtensionIconMap, b.ExtensionIconMap) {
+		change |= ChangeExtensionIconMap
+	}
+
+	if a.ActiveProfile != b.ActiveProfile || !reflect.DeepEqual(a.Profiles, b.Profiles) {
+		change |= ChangeProfiles
+	}
+	if a.Control != b.Control {
+		change |= ChangeControl
+	}
+
+	return change
+}
+
+// Clone returns a deep copy of s.
+func (s *Settings) Clone() *Settings {
+	clone := *s
+	if s.Drawers != nil {
+		clone.Drawers = make([]Drawer, len(s.Drawers))
+		for i, drawer := range s.Drawers {
+			clone.Drawers[i] = drawer.Clone()
+		}
+	}
+	clone.Groups = slices.Clone(s.Groups)
+	if s.Profiles != nil {
+		clone.Profiles = make([]Profile, len(s.Profiles))
+		for i, profile := range s.Profiles {
+			clone.Profiles[i] = profile.Clone()
+		}
+	}
+	clone.ExtensionIconMap = maps.Clone(s.ExtensionIconMap)
+	clone.Deprecated = maps.Clone(s.Deprecated)
+	return &clone
+}
+
+// Clone returns a deep copy of d.
+func (d Drawer) Clone() Drawer {
+	if d.View != nil {
+		view := *d.View
+		view.ColumnWidths = slices.Clone(view.ColumnWidths)
+		if view.Position != nil {
+			position := *view.Position
+			view.Position = &position
+		}
+		d.View = &view
+	}
+	return d
+}
+
+// Clone returns a deep copy of p.
+func (p Profile) Clone() Profile {
+	if p.Theme != nil {
+		theme := *p.Theme
+		p.Theme = &theme
+	}
+	p.ExtensionIconMap = maps.Clone(p.ExtensionIconMap)
+	return p
+}
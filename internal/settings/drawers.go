@@ -0,0 +1,203 @@
+package settings
+
+import (
+	"errors"
+	"path/filepath"
+	"strings"
+)
+
+var (
+	// ErrEmptyPath is returned when a drawer path is blank.
+	ErrEmptyPath = errors.New("path must not be empty")
+	// ErrDuplicatePath is returned when another drawer already shows the folder.
+	ErrDuplicatePath = errors.New("folder already has a drawer")
+)
+
+// DefaultDrawerSize is used for drawers added without a size.
+var DefaultDrawerSize = Size{Width: 420, Height: 360}
+
+// AddDrawer appends a drawer after validating that its name and path are
+// not used yet by a drawer shown alongside it. A missing ID or size is
+// filled in. The stored drawer is returned.
+func (s *Settings) AddDrawer(drawer Drawer) (Drawer, error) {
+	drawer.Name = strings.TrimSpace(drawer.Name)
+	if err := s.checkDrawerName("", drawer.Profile, drawer.Name); err != nil {
+		return Drawer{}, err
+	}
+	if err := s.checkDrawerPath("", drawer.Profile, drawer.Path); err != nil {
+		return Drawer{}, err
+	}
+	if drawer.Group != "" && s.GroupIndex(drawer.Group) < 0 {
+		return Drawer{}, ErrNotFound
+	}
+	if drawer.Profile != "" && s.ProfileIndex(drawer.Profile) < 0 {
+		return Drawer{}, ErrNotFound
+	}
+
+	if drawer.ID == "" || s.DrawerIndex(drawer.ID) >= 0 {
+		drawer.ID = NewDrawerID()
+	}
+	if drawer.Size.Width <= 0 || drawer.Size.Height <= 0 {
+		drawer.Size = DefaultDrawerSize
+	}
+
+	s.Drawers = append(s.Drawers, drawer)
+	return drawer, nil
+}
+
+// RemoveDrawer deletes the drawer with the given ID.
+func (s *Settings) RemoveDrawer(id string) error {
+	i := s.DrawerIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	s.Drawers = append(s.Drawers[:i], s.Drawers[i+1:]...)
+	return nil
+}
+
+// RenameDrawer changes the display name of a drawer.
+func (s *Settings) RenameDrawer(id, name string) error {
+	i := s.DrawerIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.checkDrawerName(id, s.Drawers[i].Profile, name); err != nil {
+		return err
+	}
+
+	s.Drawers[i].Name = name
+	return nil
+}
+
+// SetDrawerPath points a drawer at another folder. The remembered subpath
+// belongs to the old folder and is cleared.
+func (s *Settings) SetDrawerPath(id, path string) error {
+	i := s.DrawerIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	if err := s.checkDrawerPath(id, s.Drawers[i].Profile, path); err != nil {
+		return err
+	}
+
+	s.Drawers[i].Path = path
+	if s.Drawers[i].View != nil {
+		s.Drawers[i].View.Subpath = ""
+	}
+	return nil
+}
+
+// SetDrawerConfined keeps a drawer window inside the drawer folder, or lets
+// it leave.
+func (s *Settings) SetDrawerConfined(id string, confined bool) error {
+	i := s.DrawerIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	s.Drawers[i].Confined = confined
+	return nil
+}
+
+// MoveDrawer moves a drawer delta places up (negative) or down (positive)
+// among the drawers shown in the same group of the active profile. Moves
+// past either end stop there.
+func (s *Settings) MoveDrawer(id string, delta int) error {
+	i := s.DrawerIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	group := s.effectiveGroup(s.Drawers[i])
+	step := 1
+	if delta < 0 {
+		step, delta = -1, -delta
+	}
+
+	for ; delta > 0; delta-- {
+		j := i + step
+		for j >= 0 && j < len(s.Drawers) && (s.effectiveGroup(s.Drawers[j]) != group || !s.DrawerVisible(s.Drawers[j])) {
+			j += step
+		}
+		if j < 0 || j >= len(s.Drawers) {
+			break
+		}
+
+		s.Drawers[i], s.Drawers[j] = s.Drawers[j], s.Drawers[i]
+		i = j
+	}
+
+	return nil
+}
+
+// MoveDrawerNextTo places a drawer directly before or after target, taking
+// over the target's group.
+func (s *Settings) MoveDrawerNextTo(id, targetID string, after bool) error {
+	if id == targetID {
+		return nil
+	}
+
+	i := s.DrawerIndex(id)
+	if i < 0 || s.DrawerIndex(targetID) < 0 {
+		return ErrNotFound
+	}
+
+	drawer := s.Drawers[i]
+	s.Drawers = append(s.Drawers[:i], s.Drawers[i+1:]...)
+
+	target := s.DrawerIndex(targetID)
+	drawer.Group = s.Drawers[target].Group
+	if after {
+		target++
+	}
+
+	s.Drawers = append(s.Drawers[:target], append([]Drawer{drawer}, s.Drawers[target:]...)...)
+	return nil
+}
+
+func (s *Settings) checkDrawerName(id, profile, name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	for _, drawer := range s.Drawers {
+		if drawer.ID != id && sharesProfile(drawer.Profile, profile) && strings.EqualFold(strings.TrimSpace(drawer.Name), name) {
+			return ErrDuplicateName
+		}
+	}
+	return nil
+}
+
+func (s *Settings) checkDrawerPath(id, profile, path string) error {
+	if strings.TrimSpace(path) == "" {
+		return ErrEmptyPath
+	}
+	for _, drawer := range s.Drawers {
+		if drawer.ID != id && sharesProfile(drawer.Profile, profile) && samePath(s.ResolvePath(drawer.Path), s.ResolvePath(path)) {
+			return ErrDuplicatePath
+		}
+	}
+	return nil
+}
+
+// samePath compares folder paths the way Windows does: cleaned and without
+// regard to case.
+func samePath(a, b string) bool {
+	return pathKey(a) == pathKey(b)
+}
+
+func pathKey(path string) string {
+	return strings.ToLower(filepath.Clean(path))
+}
+
+// ViewOrDefault returns the drawer's saved view state, or the zero state
+// when it has none.
+func (d Drawer) ViewOrDefault() ViewState {
+	if d.View == nil {
+		return ViewState{}
+	}
+	return *d.View
+}
@@ -0,0 +1,303 @@
+package settings
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+// drawerFixture has a shared drawer, one per profile and two groups:
+//
+//	d1 Docs   shared   g1
+//	d2 Work   office   g1
+//	d3 Music  home     g1
+//	d4 Photos shared   g1
+//	d5 Games  shared   g2
+//	d6 Notes  shared   -
+func drawerFixture() *Settings {
+	return &Settings{
+		Groups: []Group{
+			{ID: "g1", Name: "Main"},
+			{ID: "g2", Name: "Fun"},
+		},
+		Profiles: []Profile{
+			{ID: "office", Name: "Office"},
+			{ID: "home", Name: "Home"},
+		},
+		ActiveProfile: "office",
+		Drawers: []Drawer{
+			{ID: "d1", Name: "Docs", Path: "/data/docs", Group: "g1"},
+			{ID: "d2", Name: "Work", Path: "/data/work", Group: "g1", Profile: "office"},
+			{ID: "d3", Name: "Music", Path: "/data/music", Group: "g1", Profile: "home"},
+			{ID: "d4", Name: "Photos", Path: "/data/photos", Group: "g1"},
+			{ID: "d5", Name: "Games", Path: "/data/games", Group: "g2"},
+			{ID: "d6", Name: "Notes", Path: "/data/notes"},
+		},
+	}
+}
+
+func drawerIDs(s *Settings) []string {
+	ids := make([]string, len(s.Drawers))
+	for i, drawer := range s.Drawers {
+		ids[i] = drawer.ID
+	}
+	return ids
+}
+
+func TestAddDrawer(t *testing.T) {
+	tests := []struct {
+		name   string
+		drawer Drawer
+		want   error
+	}{
+		{"new", Drawer{Name: "Videos", Path: "/data/videos"}, nil},
+		{"name in other case", Drawer{Name: "DOCS", Path: "/data/other"}, ErrDuplicateName},
+		{"name with spaces", Drawer{Name: "  Docs ", Path: "/data/other"}, ErrDuplicateName},
+		{"empty name", Drawer{Name: " ", Path: "/data/other"}, ErrEmptyName},
+		{"path in other case", Drawer{Name: "Other", Path: "/DATA/Docs"}, ErrDuplicatePath},
+		{"path not clean", Drawer{Name: "Other", Path: "/data/x/../docs/"}, ErrDuplicatePath},
+		{"empty path", Drawer{Name: "Other", Path: "  "}, ErrEmptyPath},
+		{"name of other profile", Drawer{Name: "Work", Path: "/data/other", Profile: "home"}, nil},
+		{"path of other profile", Drawer{Name: "Other", Path: "/data/work", Profile: "home"}, nil},
+		{"shared name used by a profile", Drawer{Name: "Work", Path: "/data/other"}, ErrDuplicateName},
+		{"shared path used by a profile", Drawer{Name: "Other", Path: "/data/music"}, ErrDuplicatePath},
+		{"unknown group", Drawer{Name: "Other", Path: "/data/other", Group: "g9"}, ErrNotFound},
+		{"unknown profile", Drawer{Name: "Other", Path: "/data/other", Profile: "p9"}, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := drawerFixture()
+			added, err := s.AddDrawer(tt.drawer)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("AddDrawer() error = %v, want %v", err, tt.want)
+			}
+			if err != nil {
+				if len(s.Drawers) != len(drawerFixture().Drawers) {
+					t.Errorf("drawer added despite error")
+				}
+				return
+			}
+			if added.ID == "" || added.Size != DefaultDrawerSize {
+				t.Errorf("AddDrawer() = %+v, want an id and the default size", added)
+			}
+			if last := s.Drawers[len(s.Drawers)-1]; last.ID != added.ID {
+				t.Errorf("last drawer = %s, want %s", last.ID, added.ID)
+			}
+		})
+	}
+}
+
+func TestAddDrawerReplacesTakenID(t *testing.T) {
+	s := drawerFixture()
+	added, err := s.AddDrawer(Drawer{ID: "d1", Name: "Other", Path: "/data/other"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if added.ID == "d1" {
+		t.Errorf("AddDrawer() kept the id of an existing drawer")
+	}
+}
+
+func TestRemoveDrawer(t *testing.T) {
+	s := drawerFixture()
+	if err := s.RemoveDrawer("d2"); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"d1", "d3", "d4", "d5", "d6"}; !slices.Equal(drawerIDs(s), want) {
+		t.Errorf("drawers = %v, want %v", drawerIDs(s), want)
+	}
+	if err := s.RemoveDrawer("d2"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("RemoveDrawer() of a removed drawer error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestRenameDrawer(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		newName string
+		want    error
+	}{
+		{"new name", "d1", "Papers", nil},
+		{"own name in other case", "d1", "DOCS", nil},
+		{"name of shared drawer", "d1", "photos", ErrDuplicateName},
+		{"shared drawer to name used by a profile", "d1", "Music", ErrDuplicateName},
+		{"profile drawer to name of other profile", "d3", "work", nil},
+		{"profile drawer to shared name", "d3", "Games", ErrDuplicateName},
+		{"empty name", "d1", "  ", ErrEmptyName},
+		{"unknown drawer", "d9", "Other", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := drawerFixture()
+			err := s.RenameDrawer(tt.id, tt.newName)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("RenameDrawer() error = %v, want %v", err, tt.want)
+			}
+			if err == nil {
+				if got := s.Drawers[s.DrawerIndex(tt.id)].Name; got != tt.newName {
+					t.Errorf("name = %q, want %q", got, tt.newName)
+				}
+			}
+		})
+	}
+}
+
+func TestSetDrawerPath(t *testing.T) {
+	s := drawerFixture()
+	s.Drawers[0].View = &ViewState{Subpath: "sub"}
+
+	if err := s.SetDrawerPath("d1", "/data/photos"); !errors.Is(err, ErrDuplicatePath) {
+		t.Errorf("SetDrawerPath() to a taken folder error = %v, want %v", err, ErrDuplicatePath)
+	}
+	if err := s.SetDrawerPath("d1", "/data/papers"); err != nil {
+		t.Fatal(err)
+	}
+	if d := s.Drawers[0]; d.Path != "/data/papers" || d.View.Subpath != "" {
+		t.Errorf("drawer = %+v, want the new path and no subpath", d)
+	}
+}
+
+func TestMoveDrawer(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		delta int
+		want  []string
+	}{
+		{"down past other profile", "d2", 1, []string{"d1", "d4", "d3", "d2", "d5", "d6"}},
+		{"up", "d4", -1, []string{"d1", "d4", "d3", "d2", "d5", "d6"}},
+		{"up to top", "d4", -5, []string{"d4", "d1", "d3", "d2", "d5", "d6"}},
+		{"down past end of group", "d4", 3, []string{"d1", "d2", "d3", "d4", "d5", "d6"}},
+		{"not into other group", "d5", -1, []string{"d1", "d2", "d3", "d4", "d5", "d6"}},
+		{"ungrouped stays", "d6", -2, []string{"d1", "d2", "d3", "d4", "d5", "d6"}},
+		{"zero", "d1", 0, []string{"d1", "d2", "d3", "d4", "d5", "d6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := drawerFixture()
+			if err := s.MoveDrawer(tt.id, tt.delta); err != nil {
+				t.Fatal(err)
+			}
+			if got := drawerIDs(s); !slices.Equal(got, tt.want) {
+				t.Errorf("drawers = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	if err := drawerFixture().MoveDrawer("d9", 1); !errors.Is(err, ErrNotFound) {
+		t.Errorf("MoveDrawer() of an unknown drawer error = %v, want %v", err, ErrNotFound)
+	}
+}
+
+func TestMoveDrawerInOtherProfile(t *testing.T) {
+	s := drawerFixture()
+	s.ActiveProfile = "home"
+
+	// Work belongs to the office profile and is skipped now, Music is not.
+	if err := s.MoveDrawer("d1", 1); err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"d3", "d2", "d1", "d4", "d5", "d6"}; !slices.Equal(drawerIDs(s), want) {
+		t.Errorf("drawers = %v, want %v", drawerIDs(s), want)
+	}
+}
+
+func TestMoveDrawerToGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		id, group string
+		want      []string
+		wantErr   error
+	}{
+		{"to other group", "d1", "g2", []string{"d2", "d3", "d4", "d5", "d1", "d6"}, nil},
+		{"to ungrouped", "d5", "", []string{"d1", "d2", "d3", "d4", "d6", "d5"}, nil},
+		{"ungrouped into group", "d6", "g1", []string{"d1", "d2", "d3", "d4", "d6", "d5"}, nil},
+		{"unknown group", "d1", "g9", nil, ErrNotFound},
+		{"unknown drawer", "d9", "g1", nil, ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := drawerFixture()
+			err := s.MoveDrawerToGroup(tt.id, tt.group)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveDrawerToGroup() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := drawerIDs(s); !slices.Equal(got, tt.want) {
+				t.Errorf("drawers = %v, want %v", got, tt.want)
+			}
+			if got := s.Drawers[s.DrawerIndex(tt.id)].Group; got != tt.group {
+				t.Errorf("group = %q, want %q", got, tt.group)
+			}
+		})
+	}
+}
+
+func TestMoveDrawerNextTo(t *testing.T) {
+	tests := []struct {
+		name      string
+		id        string
+		target    string
+		after     bool
+		want      []string
+		wantGroup string
+		wantErr   error
+	}{
+		{"before", "d4", "d1", false, []string{"d4", "d1", "d2", "d3", "d5", "d6"}, "g1", nil},
+		{"after", "d1", "d4", true, []string{"d2", "d3", "d4", "d1", "d5", "d6"}, "g1", nil},
+		{"into other group", "d1", "d5", true, []string{"d2", "d3", "d4", "d5", "d1", "d6"}, "g2", nil},
+		{"out of groups", "d5", "d6", false, []string{"d1", "d2", "d3", "d4", "d5", "d6"}, "", nil},
+		{"next to itself", "d1", "d1", true, []string{"d1", "d2", "d3", "d4", "d5", "d6"}, "g1", nil},
+		{"unknown target", "d1", "d9", false, nil, "", ErrNotFound},
+		{"unknown drawer", "d9", "d1", false, nil, "", ErrNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := drawerFixture()
+			err := s.MoveDrawerNextTo(tt.id, tt.target, tt.after)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("MoveDrawerNextTo() error = %v, want %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			if got := drawerIDs(s); !slices.Equal(got, tt.want) {
+				t.Errorf("drawers = %v, want %v", got, tt.want)
+			}
+			if got := s.Drawers[s.DrawerIndex(tt.id)].Group; got != tt.wantGroup {
+				t.Errorf("group = %q, want %q", got, tt.wantGroup)
+			}
+		})
+	}
+}
+
+func TestGroups(t *testing.T) {
+	s := drawerFixture()
+
+	if _, err := s.AddGroup(" main "); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("AddGroup() of a taken name error = %v, want %v", err, ErrDuplicateName)
+	}
+	if err := s.RenameGroup("g2", "MAIN"); !errors.Is(err, ErrDuplicateName) {
+		t.Errorf("RenameGroup() to a taken name error = %v, want %v", err, ErrDuplicateName)
+	}
+	if err := s.RenameGroup("g1", "MAIN"); err != nil {
+		t.Errorf("RenameGroup() to its own name in other case error = %v", err)
+	}
+
+	if err := s.RemoveGroup("g1"); err != nil {
+		t.Fatal(err)
+	}
+	for _, drawer := range s.Drawers[:4] {
+		if drawer.Group != "" {
+			t.Errorf("drawer %s still in removed group", drawer.ID)
+		}
+	}
+}
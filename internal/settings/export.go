@@ -0,0 +1,75 @@
+package settings
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Format selects how Export writes settings.
+type Format string
+
+const (
+	// FormatText is the human-readable listing of Print.
+	FormatText Format = "text"
+	// FormatTOML is a complete settings file.
+	FormatTOML Format = "toml"
+	// FormatJSON uses the same keys as the settings file.
+	FormatJSON Format = "json"
+)
+
+// ParseFormat returns the Format called name.
+func ParseFormat(name string) (Format, error) {
+	switch format := Format(name); format {
+	case FormatText, FormatTOML, FormatJSON:
+		return format, nil
+	default:
+		return "", fmt.Errorf("unknown format %q, expected text, toml or json", name)
+	}
+}
+
+// redactedToken replaces the control API token in exported settings.
+const redactedToken = "REDACTED"
+
+// Export writes the effective settings, baseline included, to w. Unlike the
+// settings file, the TOML and JSON output list every value. The control API
+// token is replaced by a placeholder unless secrets is set.
+func Export(w io.Writer, settings *Settings, format Format, secrets bool) error {
+	if !secrets && settings.Control.Token != "" {
+		settings = settings.Clone()
+		settings.Control.Token = redactedToken
+	}
+
+	switch format {
+	case FormatText:
+		Fprint(w, settings)
+		return nil
+	case FormatTOML:
+		if err := toml.NewEncoder(w).Encode(settings); err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+		return nil
+	case FormatJSON:
+		// Going through TOML keeps the key names of the settings file.
+		var buf bytes.Buffer
+		if err := toml.NewEncoder(&buf).Encode(settings); err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+		var doc map[string]any
+		if _, err := toml.Decode(buf.String(), &doc); err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(doc); err != nil {
+			return fmt.Errorf("failed to encode settings: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
@@ -0,0 +1,32 @@
+package settings
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestExportRedactsToken(t *testing.T) {
+	s := &Settings{Control: Control{Enabled: true, Token: "secret"}}
+
+	for _, format := range []Format{FormatText, FormatTOML, FormatJSON} {
+		var buf bytes.Buffer
+		if err := Export(&buf, s, format, false); err != nil {
+			t.Fatalf("Export(%s) = %v", format, err)
+		}
+		if strings.Contains(buf.String(), "secret") {
+			t.Errorf("Export(%s) includes the token:\n%s", format, buf.String())
+		}
+
+		buf.Reset()
+		if err := Export(&buf, s, format, true); err != nil {
+			t.Fatalf("Export(%s) with secrets = %v", format, err)
+		}
+		if format != FormatText && !strings.Contains(buf.String(), "secret") {
+			t.Errorf("Export(%s) with secrets lacks the token:\n%s", format, buf.String())
+		}
+	}
+	if s.Control.Token != "secret" {
+		t.Errorf("Export changed the token to %q", s.Control.Token)
+	}
+}
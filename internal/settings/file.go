@@ -0,0 +1,149 @@
+package settings
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// BackupGenerations is the number of previous settings files kept as
+// <path>.1.bak (newest) through <path>.N.bak (oldest).
+const BackupGenerations = 3
+
+// backupPath returns the name of the given backup generation.
+func backupPath(path string, generation int) string {
+	return fmt.Sprintf("%s.%d.bak", path, generation)
+}
+
+// backupPaths lists the existing backups of path, newest first.
+func backupPaths(path string) []string {
+	var paths []string
+	for generation := 1; generation <= BackupGenerations; generation++ {
+		candidate := backupPath(path, generation)
+		if _, err := os.Stat(candidate); err == nil {
+			paths = append(paths, candidate)
+		}
+	}
+	return paths
+}
+
+// writeFileAtomic replaces path with data without ever leaving a partially
+// written file behind. The data goes to a temporary file in the same
+// directory, is flushed to disk and then renamed over path. The file being
+// replaced is kept as the newest backup generation.
+func writeFileAtomic(path string, data []byte) error {
+	return replaceFile(path, data, true)
+}
+
+// replaceFile is writeFileAtomic with the backup rotation made optional, for
+// files that do not need backups of their own.
+func replaceFile(path string, data []byte, backup bool) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temporary settings file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmpPath)
+		}
+	}()
+
+	// CreateTemp makes the file private; keep the mode of the file being
+	// replaced instead, or the usual one for a new file.
+	mode := os.FileMode(0o644)
+	if info, statErr := os.Stat(path); statErr == nil {
+		mode = info.Mode().Perm()
+	}
+	if err = tmp.Chmod(mode); err != nil {
+		return fmt.Errorf("failed to set mode of temporary settings file: %w", err)
+	}
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("failed to write temporary settings file: %w", err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("failed to flush temporary settings file: %w", err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("failed to close temporary settings file: %w", err)
+	}
+
+	if backup {
+		if err = rotateBackups(path); err != nil {
+			return err
+		}
+	}
+
+	if err = os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to replace settings file: %w", err)
+	}
+
+	syncDir(dir)
+	return nil
+}
+
+// rotateBackups shifts every backup generation down by one and copies the
+// current file into the newest slot. Nothing happens when path is missing.
+func rotateBackups(path string) error {
+	if _, err := os.Stat(path); os.IsNotExist(err) {
+		return nil
+	}
+
+	oldest := backupPath(path, BackupGenerations)
+	if err := os.Remove(oldest); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to drop oldest settings backup: %w", err)
+	}
+
+	for generation := BackupGenerations - 1; generation >= 1; generation-- {
+		from := backupPath(path, generation)
+		if err := os.Rename(from, backupPath(path, generation+1)); err != nil && !os.IsNotExist(err) {
+			return fmt.Errorf("failed to rotate settings backup: %w", err)
+		}
+	}
+
+	if err := copyFile(path, backupPath(path, 1)); err != nil {
+		return fmt.Errorf("failed to back up settings file: %w", err)
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+
+	return out.Close()
+}
+
+// syncDir flushes the directory entry after a rename where the platform
+// supports it. Failures are ignored; the rename itself already succeeded.
+func syncDir(dir string) {
+	d, err := os.Open(dir)
+	if err != nil {
+		return
+	}
+	d.Sync()
+	d.Close()
+}
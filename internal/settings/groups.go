@@ -0,0 +1,136 @@
+package settings
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	// ErrEmptyName is returned when a drawer or group name is blank.
+	ErrEmptyName = errors.New("name must not be empty")
+	// ErrDuplicateName is returned when a name is already in use.
+	ErrDuplicateName = errors.New("name is already in use")
+	// ErrNotFound is returned when no drawer or group has the given id.
+	ErrNotFound = errors.New("not found")
+)
+
+// GroupIndex returns the index of the group with the given ID, or -1.
+func (s *Settings) GroupIndex(id string) int {
+	for i := range s.Groups {
+		if s.Groups[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// DrawersInGroup returns the drawers of the active profile shown in the
+// given group, in list order. The empty groupID selects ungrouped drawers,
+// which includes drawers pointing at a group that no longer exists.
+func (s *Settings) DrawersInGroup(groupID string) []Drawer {
+	var drawers []Drawer
+	for _, drawer := range s.Drawers {
+		if s.effectiveGroup(drawer) == groupID && s.DrawerVisible(drawer) {
+			drawers = append(drawers, drawer)
+		}
+	}
+	return drawers
+}
+
+func (s *Settings) effectiveGroup(drawer Drawer) string {
+	if drawer.Group == "" || s.GroupIndex(drawer.Group) < 0 {
+		return ""
+	}
+	return drawer.Group
+}
+
+// AddGroup appends a new, expanded group and returns it.
+func (s *Settings) AddGroup(name string) (Group, error) {
+	name = strings.TrimSpace(name)
+	if err := s.checkGroupName("", name); err != nil {
+		return Group{}, err
+	}
+
+	group := Group{ID: NewGroupID(), Name: name}
+	s.Groups = append(s.Groups, group)
+	return group, nil
+}
+
+// RenameGroup changes the name of a group.
+func (s *Settings) RenameGroup(id, name string) error {
+	i := s.GroupIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.checkGroupName(id, name); err != nil {
+		return err
+	}
+
+	s.Groups[i].Name = name
+	return nil
+}
+
+// RemoveGroup deletes a group. Its drawers become ungrouped.
+func (s *Settings) RemoveGroup(id string) error {
+	i := s.GroupIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	s.Groups = append(s.Groups[:i], s.Groups[i+1:]...)
+	for j := range s.Drawers {
+		if s.Drawers[j].Group == id {
+			s.Drawers[j].Group = ""
+		}
+	}
+	return nil
+}
+
+// SetGroupCollapsed records whether a group is shown collapsed.
+func (s *Settings) SetGroupCollapsed(id string, collapsed bool) error {
+	i := s.GroupIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	s.Groups[i].Collapsed = collapsed
+	return nil
+}
+
+// MoveDrawerToGroup assigns a drawer to a group and places it after the
+// group's last drawer. The empty groupID makes the drawer ungrouped.
+func (s *Settings) MoveDrawerToGroup(drawerID, groupID string) error {
+	i := s.DrawerIndex(drawerID)
+	if i < 0 || (groupID != "" && s.GroupIndex(groupID) < 0) {
+		return ErrNotFound
+	}
+
+	drawer := s.Drawers[i]
+	drawer.Group = groupID
+	s.Drawers = append(s.Drawers[:i], s.Drawers[i+1:]...)
+
+	insert := len(s.Drawers)
+	for j := len(s.Drawers) - 1; j >= 0; j-- {
+		if s.effectiveGroup(s.Drawers[j]) == groupID {
+			insert = j + 1
+			break
+		}
+	}
+
+	s.Drawers = append(s.Drawers[:insert], append([]Drawer{drawer}, s.Drawers[insert:]...)...)
+	return nil
+}
+
+func (s *Settings) checkGroupName(id, name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	for _, group := range s.Groups {
+		if group.ID != id && strings.EqualFold(group.Name, name) {
+			return ErrDuplicateName
+		}
+	}
+	return nil
+}
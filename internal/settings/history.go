@@ -0,0 +1,199 @@
+package settings
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+)
+
+// DefaultHistoryLimit is the number of previous settings states a History
+// keeps for undo.
+const DefaultHistoryLimit = 50
+
+var (
+	// ErrNothingToUndo is returned by Store.Undo when the history is empty.
+	ErrNothingToUndo = errors.New("nothing to undo")
+	// ErrNothingToRedo is returned by Store.Redo when nothing was undone.
+	ErrNothingToRedo = errors.New("nothing to redo")
+	// ErrUnknownSnapshot is returned by Store.Restore for an ID that is not
+	// in the history.
+	ErrUnknownSnapshot = errors.New("snapshot not found")
+)
+
+// Snapshot is a previous state of the settings.
+type Snapshot struct {
+	ID   int       `toml:"id"`
+	Time time.Time `toml:"time"`
+	// Cause is the change that was made to this state, e.g. "remove
+	// drawer". Undoing that change brings the state back.
+	Cause    string    `toml:"cause"`
+	Settings *Settings `toml:"settings"`
+}
+
+// String returns the snapshot as shown in history lists.
+func (s Snapshot) String() string {
+	return fmt.Sprintf("#%d  %s  before %s", s.ID, s.Time.Local().Format("2006-01-02 15:04:05"), s.Cause)
+}
+
+// History holds the undo and redo states of a settings file. It is kept in
+// a sidecar file next to it, see HistoryPath. A History is not safe for
+// concurrent use; Store guards its own.
+type History struct {
+	NextID int        `toml:"next_id"`
+	Undo   []Snapshot `toml:"undo,omitempty"`
+	Redo   []Snapshot `toml:"redo,omitempty"`
+
+	limit int
+	// changes counts modifications; written is its value at the last write.
+	changes int
+	written int
+}
+
+// HistoryPath returns the history file kept next to the settings file at
+// path: goDrawer-settings.toml has goDrawer-settings.history.toml.
+func HistoryPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".history" + ext
+}
+
+// NewHistory returns an empty history keeping up to limit undo states.
+// Zero means DefaultHistoryLimit.
+func NewHistory(limit int) *History {
+	if limit <= 0 {
+		limit = DefaultHistoryLimit
+	}
+	return &History{NextID: 1, limit: limit}
+}
+
+// LoadHistory reads the history file at path. A missing file yields an
+// empty history. States written for another schema version are dropped, as
+// they can no longer be restored faithfully.
+func LoadHistory(path string, limit int) (*History, error) {
+	history := NewHistory(limit)
+
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return history, nil
+	}
+	if err != nil {
+		return history, fmt.Errorf("failed to read settings history: %w", err)
+	}
+
+	if _, err := toml.Decode(string(data), history); err != nil {
+		return NewHistory(limit), fmt.Errorf("failed to parse settings history: %w", err)
+	}
+
+	history.Undo = currentSnapshots(history.Undo)
+	history.Redo = currentSnapshots(history.Redo)
+	history.trim()
+	for _, snapshot := range append(history.Undo, history.Redo...) {
+		history.NextID = max(history.NextID, snapshot.ID+1)
+	}
+	return history, nil
+}
+
+func currentSnapshots(snapshots []Snapshot) []Snapshot {
+	kept := snapshots[:0]
+	for _, snapshot := range snapshots {
+		if snapshot.Settings != nil && snapshot.Settings.SchemaVersion == CurrentSchemaVersion {
+			snapshot.Settings.applyDefaults()
+			kept = append(kept, snapshot)
+		}
+	}
+	return kept
+}
+
+// Snapshots returns the undo states, newest first.
+func (h *History) Snapshots() []Snapshot {
+	snapshots := make([]Snapshot, len(h.Undo))
+	for i, snapshot := range h.Undo {
+		snapshots[len(h.Undo)-1-i] = snapshot
+	}
+	return snapshots
+}
+
+// record adds state, the settings before the change cause, to the undo
+// states. A new change makes the redo states unreachable, so they are
+// dropped.
+func (h *History) record(cause string, state *Settings) {
+	h.Undo = append(h.Undo, h.snapshot(cause, state))
+	h.Redo = nil
+	h.trim()
+	h.changes++
+}
+
+func (h *History) snapshot(cause string, state *Settings) Snapshot {
+	snapshot := Snapshot{ID: h.NextID, Time: time.Now().UTC().Truncate(time.Second), Cause: cause, Settings: state}
+	h.NextID++
+	return snapshot
+}
+
+// undo moves the newest undo state to the caller, remembering current so
+// the step can be redone.
+func (h *History) undo(current *Settings) (Snapshot, bool) {
+	if len(h.Undo) == 0 {
+		return Snapshot{}, false
+	}
+	last := h.Undo[len(h.Undo)-1]
+	h.Undo = h.Undo[:len(h.Undo)-1]
+	h.Redo = append(h.Redo, h.snapshot(last.Cause, current))
+	h.changes++
+	return last, true
+}
+
+// redo is the reverse of undo.
+func (h *History) redo(current *Settings) (Snapshot, bool) {
+	if len(h.Redo) == 0 {
+		return Snapshot{}, false
+	}
+	last := h.Redo[len(h.Redo)-1]
+	h.Redo = h.Redo[:len(h.Redo)-1]
+	h.Undo = append(h.Undo, h.snapshot(last.Cause, current))
+	h.trim()
+	h.changes++
+	return last, true
+}
+
+// find returns the undo state with the given ID.
+func (h *History) find(id int) (Snapshot, bool) {
+	for _, snapshot := range h.Undo {
+		if snapshot.ID == id {
+			return snapshot, true
+		}
+	}
+	return Snapshot{}, false
+}
+
+func (h *History) trim() {
+	if extra := len(h.Undo) - h.limit; extra > 0 {
+		h.Undo = append([]Snapshot(nil), h.Undo[extra:]...)
+	}
+}
+
+// pending returns the encoded history and the change count it reflects, or
+// nil when it was written already.
+func (h *History) pending() ([]byte, int, error) {
+	if h.changes == h.written {
+		return nil, 0, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(h); err != nil {
+		return nil, 0, fmt.Errorf("failed to encode settings history: %w", err)
+	}
+	return buf.Bytes(), h.changes, nil
+}
+
+// lastCause returns the cause of the newest entry of snapshots.
+func lastCause(snapshots []Snapshot) (string, bool) {
+	if len(snapshots) == 0 {
+		return "", false
+	}
+	return snapshots[len(snapshots)-1].Cause, true
+}
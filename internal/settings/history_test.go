@@ -0,0 +1,180 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+)
+
+func stateAt(x int) *Settings {
+	s := &Settings{SchemaVersion: CurrentSchemaVersion, WindowPosition: Point{X: x}}
+	s.applyDefaults()
+	return s
+}
+
+func undoXs(h *History) []int {
+	var xs []int
+	for _, snapshot := range h.Undo {
+		xs = append(xs, snapshot.Settings.WindowPosition.X)
+	}
+	return xs
+}
+
+func TestHistoryTrimsToLimit(t *testing.T) {
+	h := NewHistory(3)
+	for x := 1; x <= 5; x++ {
+		h.record("move", stateAt(x))
+	}
+
+	if got, want := undoXs(h), []int{3, 4, 5}; !slices.Equal(got, want) {
+		t.Errorf("undo states = %v, want %v", got, want)
+	}
+
+	// Redoing after undo must not grow past the limit either.
+	for range 3 {
+		if _, ok := h.undo(stateAt(9)); !ok {
+			t.Fatal("undo failed")
+		}
+	}
+	for range 3 {
+		if _, ok := h.redo(stateAt(9)); !ok {
+			t.Fatal("redo failed")
+		}
+	}
+	if len(h.Undo) != 3 {
+		t.Errorf("%d undo states after redo, want 3", len(h.Undo))
+	}
+}
+
+func TestHistoryNewChangeClearsRedo(t *testing.T) {
+	h := NewHistory(0)
+	h.record("move", stateAt(1))
+	h.record("move", stateAt(2))
+
+	snapshot, ok := h.undo(stateAt(3))
+	if !ok || snapshot.Settings.WindowPosition.X != 2 {
+		t.Fatalf("undo = %v, %v, want the state at 2", snapshot, ok)
+	}
+	if len(h.Redo) != 1 {
+		t.Fatalf("%d redo states after undo, want 1", len(h.Redo))
+	}
+
+	h.record("rename", stateAt(2))
+	if len(h.Redo) != 0 {
+		t.Errorf("redo states left after a new change: %v", h.Redo)
+	}
+	if _, ok := h.redo(stateAt(4)); ok {
+		t.Errorf("redo succeeded after a new change")
+	}
+}
+
+func TestHistorySnapshotsNewestFirst(t *testing.T) {
+	h := NewHistory(0)
+	h.record("first", stateAt(1))
+	h.record("second", stateAt(2))
+
+	snapshots := h.Snapshots()
+	if len(snapshots) != 2 || snapshots[0].Cause != "second" || snapshots[1].Cause != "first" {
+		t.Errorf("Snapshots = %v, want second then first", snapshots)
+	}
+	if snapshots[0].ID <= snapshots[1].ID {
+		t.Errorf("IDs not increasing: %d, %d", snapshots[1].ID, snapshots[0].ID)
+	}
+	if found, ok := h.find(snapshots[1].ID); !ok || found.Cause != "first" {
+		t.Errorf("find(%d) = %v, %v", snapshots[1].ID, found, ok)
+	}
+}
+
+func TestLoadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "goDrawer-settings.history.toml")
+
+	h, err := LoadHistory(path, 0)
+	if err != nil || len(h.Undo) != 0 || h.NextID != 1 {
+		t.Fatalf("LoadHistory of a missing file = %+v, %v, want an empty history", h, err)
+	}
+
+	h.record("move", stateAt(1))
+	h.record("move", stateAt(2))
+	h.Undo[0].Settings.SchemaVersion = CurrentSchemaVersion - 1
+	h.undo(stateAt(3))
+	data, _, err := h.pending()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadHistory(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The state of an older schema cannot be restored and is dropped.
+	if len(loaded.Undo) != 0 || len(loaded.Redo) != 1 || loaded.Redo[0].Settings.WindowPosition.X != 3 {
+		t.Errorf("loaded undo %v, redo %v, want only the redo state at 3", loaded.Undo, loaded.Redo)
+	}
+	if loaded.NextID != h.NextID {
+		t.Errorf("NextID = %d, want %d", loaded.NextID, h.NextID)
+	}
+
+	if err := os.WriteFile(path, []byte("undo = 5"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if broken, err := LoadHistory(path, 0); err == nil || len(broken.Undo) != 0 {
+		t.Errorf("LoadHistory of a broken file = %v, %v, want an empty history and an error", broken, err)
+	}
+}
+
+func TestStoreUndoRedo(t *testing.T) {
+	st := newTestStore(t, time.Hour)
+	for x := 2; x <= 3; x++ {
+		if err := st.Update("move", moveWindow(x)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := st.Adjust("nudge", moveWindow(4)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if x := st.Snapshot().WindowPosition.X; x != 2 {
+		t.Errorf("after undo x = %d, want 2", x)
+	}
+	if cause, ok := st.RedoCause(); !ok || cause != "move" {
+		t.Errorf("RedoCause = %q, %v", cause, ok)
+	}
+	if err := st.Redo(); err != nil {
+		t.Fatal(err)
+	}
+	if x := st.Snapshot().WindowPosition.X; x != 4 {
+		t.Errorf("after redo x = %d, want 4", x)
+	}
+	if err := st.Redo(); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("second Redo = %v, want ErrNothingToRedo", err)
+	}
+
+	// The history is written next to the settings and read back by the
+	// next store.
+	if err := st.Flush(); err != nil {
+		t.Fatal(err)
+	}
+	history, err := LoadHistory(HistoryPath(st.Path()), 0)
+	if err != nil || len(history.Undo) != 2 {
+		t.Errorf("history file has %d undo states (%v), want 2", len(history.Undo), err)
+	}
+
+	if err := st.Restore(history.Undo[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	if x := st.Snapshot().WindowPosition.X; x != 1 {
+		t.Errorf("after restore x = %d, want 1", x)
+	}
+	if err := st.Restore(999); !errors.Is(err, ErrUnknownSnapshot) {
+		t.Errorf("Restore(999) = %v, want ErrUnknownSnapshot", err)
+	}
+}
@@ -0,0 +1,112 @@
+package settings
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// NewDrawerID returns a fresh random drawer identifier.
+func NewDrawerID() string {
+	return newID("d-")
+}
+
+// NewGroupID returns a fresh random group identifier.
+func NewGroupID() string {
+	return newID("g-")
+}
+
+// NewProfileID returns a fresh random profile identifier.
+func NewProfileID() string {
+	return newID("p-")
+}
+
+func newID(prefix string) string {
+	var buf [8]byte
+	if _, err := rand.Read(buf[:]); err != nil {
+		panic("settings: failed to generate id: " + err.Error())
+	}
+	return prefix + hex.EncodeToString(buf[:])
+}
+
+// derivedID returns an id made from a digest of name, which is the same on
+// every call for the same name unless taken has it; then the digest of
+// name with a counter is tried.
+func derivedID(prefix, name string, taken map[string]bool) string {
+	seed := strings.ToLower(strings.TrimSpace(name))
+	for n := 0; ; n++ {
+		text := seed
+		if n > 0 {
+			text = fmt.Sprintf("%s#%d", seed, n)
+		}
+		sum := sha256.Sum256([]byte(text))
+		if id := prefix + hex.EncodeToString(sum[:8]); !taken[id] {
+			return id
+		}
+	}
+}
+
+// DrawerIndex returns the index of the drawer with the given ID, or -1.
+func (s *Settings) DrawerIndex(id string) int {
+	for i := range s.Drawers {
+		if s.Drawers[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// ensureDrawerIDs gives every drawer without an ID, or with an ID already
+// used by an earlier drawer (a copied block), a new one and reports how many
+// were assigned.
+func (s *Settings) ensureDrawerIDs() int {
+	assigned := 0
+	seen := map[string]bool{}
+	for i := range s.Drawers {
+		if id := s.Drawers[i].ID; id == "" || seen[id] {
+			s.Drawers[i].ID = NewDrawerID()
+			assigned++
+		}
+		seen[s.Drawers[i].ID] = true
+	}
+	return assigned
+}
+
+// ensureGroupIDs is ensureDrawerIDs for groups, except that the new ids
+// are derived from the group names. Profiles and view state refer to
+// groups by id, so a hand-written group keeps the same id on every load
+// even when the file is not written back.
+func (s *Settings) ensureGroupIDs() int {
+	taken := map[string]bool{}
+	for _, group := range s.Groups {
+		taken[group.ID] = true
+	}
+
+	assigned := 0
+	seen := map[string]bool{}
+	for i := range s.Groups {
+		if id := s.Groups[i].ID; id == "" || seen[id] {
+			s.Groups[i].ID = derivedID("g-", s.Groups[i].Name, taken)
+			taken[s.Groups[i].ID] = true
+			assigned++
+		}
+		seen[s.Groups[i].ID] = true
+	}
+	return assigned
+}
+
+// ensureProfileIDs is ensureDrawerIDs for profiles.
+func (s *Settings) ensureProfileIDs() int {
+	assigned := 0
+	seen := map[string]bool{}
+	for i := range s.Profiles {
+		if id := s.Profiles[i].ID; id == "" || seen[id] {
+			s.Profiles[i].ID = NewProfileID()
+			assigned++
+		}
+		seen[s.Profiles[i].ID] = true
+	}
+	return assigned
+}
@@ -0,0 +1,41 @@
+//go:build !windows
+
+package settings
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// knownFolder resolves a folder token to the closest freedesktop.org
+// equivalent. Tokens without one are not resolved.
+func knownFolder(name string) (string, bool) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", false
+	}
+
+	dataHome := os.Getenv("XDG_DATA_HOME")
+	if dataHome == "" {
+		dataHome = filepath.Join(home, ".local", "share")
+	}
+
+	switch name {
+	case "Home":
+		return home, true
+	case "Desktop", "Documents", "Downloads", "Pictures", "Music", "Videos":
+		return filepath.Join(home, name), true
+	case "AppData":
+		dir, err := os.UserConfigDir()
+		return dir, err == nil
+	case "LocalAppData":
+		return dataHome, true
+	case "StartMenu", "Programs":
+		return filepath.Join(dataHome, "applications"), true
+	case "Startup":
+		dir, err := os.UserConfigDir()
+		return filepath.Join(dir, "autostart"), err == nil
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,35 @@
+package settings
+
+import "golang.org/x/sys/windows"
+
+var knownFolderIDs = map[string]*windows.KNOWNFOLDERID{
+	"Home":         windows.FOLDERID_Profile,
+	"Desktop":      windows.FOLDERID_Desktop,
+	"Documents":    windows.FOLDERID_Documents,
+	"Downloads":    windows.FOLDERID_Downloads,
+	"Pictures":     windows.FOLDERID_Pictures,
+	"Music":        windows.FOLDERID_Music,
+	"Videos":       windows.FOLDERID_Videos,
+	"AppData":      windows.FOLDERID_RoamingAppData,
+	"LocalAppData": windows.FOLDERID_LocalAppData,
+	"ProgramData":  windows.FOLDERID_ProgramData,
+	"ProgramFiles": windows.FOLDERID_ProgramFiles,
+	"StartMenu":    windows.FOLDERID_StartMenu,
+	"Programs":     windows.FOLDERID_Programs,
+	"Startup":      windows.FOLDERID_Startup,
+}
+
+// knownFolder resolves a folder token through the shell's known folders,
+// which follow redirected folders such as a Desktop moved to OneDrive.
+func knownFolder(name string) (string, bool) {
+	id, ok := knownFolderIDs[name]
+	if !ok {
+		return "", false
+	}
+
+	path, err := windows.KnownFolderPath(id, windows.KF_FLAG_DEFAULT)
+	if err != nil || path == "" {
+		return "", false
+	}
+	return path, true
+}
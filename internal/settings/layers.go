@@ -0,0 +1,463 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Settings are read in layers. The built-in defaults come first, then an
+// optional read-only baseline shared by every user of a machine, then the
+// user's own file. Later layers win:
+//
+//   - tables, including extension_icon_map, are merged key by key;
+//   - drawers, groups and profiles are merged by id. A user entry with the
+//     id of a baseline entry only overrides the keys it sets; entries with
+//     new ids are added after the baseline ones;
+//   - hidden_drawers, hidden_groups and hidden_profiles in the user file
+//     list baseline ids the user removed;
+//   - hidden_keys lists other baseline keys the user removed, such as an
+//     extension_icon_map entry, as dotted paths like
+//     'extension_icon_map.".txt"'. Inside a drawer, group or profile entry
+//     it lists keys of the baseline entry with the same id;
+//   - everything else is replaced.
+//
+// Update writes only what differs from the baseline back to the user file,
+// including the hidden lists.
+
+// BaselineFileName is the name of the baseline file.
+const BaselineFileName = "goDrawer-baseline.toml"
+
+// EnvBaselinePath names the environment variable that points at a baseline
+// file.
+const EnvBaselinePath = "GODRAWER_BASELINE"
+
+// layeredLists maps every array of tables that is merged by id to the key
+// listing the baseline ids the user removed.
+var layeredLists = map[string]string{
+	"drawers":  "hidden_drawers",
+	"groups":   "hidden_groups",
+	"profiles": "hidden_profiles",
+}
+
+// hiddenKeys is the key of a user table listing the baseline keys removed
+// from it.
+const hiddenKeys = "hidden_keys"
+
+// LocateBaseline returns the path of the baseline file, or "" when there
+// is none. The first existing file wins: the GODRAWER_BASELINE environment
+// variable, goDrawer-baseline.toml next to the executable, and the machine
+// config directory (%ProgramData%\goDrawer on Windows, /etc/goDrawer
+// elsewhere).
+func LocateBaseline() string {
+	if envPath := os.Getenv(EnvBaselinePath); envPath != "" {
+		return envPath
+	}
+
+	var candidates []string
+	if exe, err := os.Executable(); err == nil {
+		candidates = append(candidates, filepath.Join(filepath.Dir(exe), BaselineFileName))
+	}
+	if runtime.GOOS == "windows" {
+		if programData := os.Getenv("ProgramData"); programData != "" {
+			candidates = append(candidates, filepath.Join(programData, "goDrawer", BaselineFileName))
+		}
+	} else {
+		candidates = append(candidates, filepath.Join("/etc", "goDrawer", BaselineFileName))
+	}
+
+	for _, candidate := range candidates {
+		if _, err := os.Stat(candidate); err == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// baseline is a baseline file as read.
+type baseline struct {
+	path string
+	// data is the file as read, for locating keys in it.
+	data []byte
+	// doc is the migrated document merged over the built-in defaults.
+	doc map[string]any
+}
+
+// document returns the baseline document, or nil when there is no
+// baseline.
+func (b *baseline) document() map[string]any {
+	if b == nil {
+		return nil
+	}
+	return b.doc
+}
+
+// loadBaseline reads and migrates the baseline file at path. The file
+// itself is never written. Entries of merged lists need an id, since user
+// entries refer to them by it.
+func loadBaseline(path string) (*baseline, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read baseline: %w", err)
+	}
+
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse baseline: %w", err)
+	}
+
+	for key := range layeredLists {
+		entries, _ := tableList(doc[key])
+		for i, entry := range entries {
+			if id, _ := entry["id"].(string); id == "" {
+				return nil, fmt.Errorf("baseline %s[%d] has no id", key, i)
+			}
+		}
+	}
+
+	if err := migrate(doc, &Report{}); err != nil {
+		return nil, fmt.Errorf("failed to migrate baseline: %w", err)
+	}
+
+	defaults, err := defaultDocument()
+	if err != nil {
+		return nil, err
+	}
+	return &baseline{path: path, data: data, doc: mergeTables(defaults, doc)}, nil
+}
+
+// defaultDocument returns the built-in defaults as a raw document, the
+// bottom layer under the baseline.
+func defaultDocument() (map[string]any, error) {
+	var defaults Settings
+	defaults.applyDefaults()
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(&defaults); err != nil {
+		return nil, fmt.Errorf("failed to encode default settings: %w", err)
+	}
+
+	doc := map[string]any{}
+	if _, err := toml.Decode(buf.String(), &doc); err != nil {
+		return nil, fmt.Errorf("failed to decode default settings: %w", err)
+	}
+	return doc, nil
+}
+
+// mergeLayers returns user laid over base. Neither argument is modified.
+func mergeLayers(base, user map[string]any) map[string]any {
+	merged := mergeMasked(base, user)
+
+	for key, hiddenKey := range layeredLists {
+		delete(merged, hiddenKey)
+
+		baseEntries, _ := tableList(base[key])
+		userEntries, ok := tableList(user[key])
+		if !ok && len(baseEntries) == 0 {
+			continue
+		}
+
+		hidden := map[string]bool{}
+		if ids, ok := user[hiddenKey].([]any); ok {
+			for _, id := range ids {
+				if id, ok := id.(string); ok {
+					hidden[id] = true
+				}
+			}
+		}
+
+		var entries []map[string]any
+		used := map[int]bool{}
+		for _, baseEntry := range baseEntries {
+			id, _ := baseEntry["id"].(string)
+			if hidden[id] {
+				continue
+			}
+			entry := baseEntry
+			if i := indexByID(userEntries, id); i >= 0 {
+				entry = mergeMasked(baseEntry, userEntries[i])
+				used[i] = true
+			}
+			entries = append(entries, entry)
+		}
+		for i, userEntry := range userEntries {
+			if !used[i] {
+				entries = append(entries, mergeMasked(nil, userEntry))
+			}
+		}
+
+		if entries == nil {
+			delete(merged, key)
+		} else {
+			merged[key] = entries
+		}
+	}
+
+	return merged
+}
+
+// mergeMasked is mergeTables with the keys user lists in hidden_keys
+// taken out of base first.
+func mergeMasked(base, user map[string]any) map[string]any {
+	paths, _ := user[hiddenKeys].([]any)
+	for _, p := range paths {
+		p, ok := p.(string)
+		if !ok {
+			continue
+		}
+		if parts, next, err := parseKey(p, 0); err == nil && next == len(p) {
+			base = withoutKey(base, parts)
+		}
+	}
+
+	merged := mergeTables(base, user)
+	delete(merged, hiddenKeys)
+	return merged
+}
+
+// withoutKey returns table without the key at path, copying the tables on
+// the way so that table itself is left as it is.
+func withoutKey(table map[string]any, path []string) map[string]any {
+	value, ok := table[path[0]]
+	if !ok {
+		return table
+	}
+
+	table = maps.Clone(table)
+	if len(path) == 1 {
+		delete(table, path[0])
+		return table
+	}
+	if sub, ok := value.(map[string]any); ok {
+		table[path[0]] = withoutKey(sub, path[1:])
+	}
+	return table
+}
+
+// mergeTables merges user into a copy of base, recursing into tables that
+// exist on both sides.
+func mergeTables(base, user map[string]any) map[string]any {
+	merged := maps.Clone(base)
+	if merged == nil {
+		merged = map[string]any{}
+	}
+
+	for key, value := range user {
+		baseTable, baseOK := merged[key].(map[string]any)
+		userTable, userOK := value.(map[string]any)
+		if baseOK && userOK {
+			merged[key] = mergeTables(baseTable, userTable)
+		} else {
+			merged[key] = value
+		}
+	}
+
+	return merged
+}
+
+// userDelta returns the part of desired that differs from base: the
+// smallest user document that mergeLayers turns back into desired.
+func userDelta(desired, base map[string]any) map[string]any {
+	settingsType := reflect.TypeFor[Settings]()
+	delta, removed := subtractTable(desired, base, settingsType)
+	removed = slices.DeleteFunc(removed, func(p any) bool {
+		_, layered := layeredLists[p.(string)]
+		return layered
+	})
+	if len(removed) > 0 {
+		delta[hiddenKeys] = removed
+	}
+
+	for key, hiddenKey := range layeredLists {
+		delete(delta, key)
+		field, _ := fieldByKey(settingsType, key)
+
+		desiredEntries, _ := tableList(desired[key])
+		baseEntries, _ := tableList(base[key])
+
+		var entries []map[string]any
+		for _, entry := range desiredEntries {
+			id, _ := entry["id"].(string)
+			i := indexByID(baseEntries, id)
+			if i < 0 {
+				entries = append(entries, entry)
+				continue
+			}
+			diff, removed := subtractTable(entry, baseEntries[i], field.Type)
+			if len(removed) > 0 {
+				diff[hiddenKeys] = removed
+			}
+			if len(diff) > 0 {
+				diff["id"] = id
+				entries = append(entries, diff)
+			}
+		}
+		if len(entries) > 0 {
+			delta[key] = entries
+		}
+
+		var hidden []any
+		for _, entry := range baseEntries {
+			id, _ := entry["id"].(string)
+			if indexByID(desiredEntries, id) < 0 {
+				hidden = append(hidden, id)
+			}
+		}
+		if len(hidden) > 0 {
+			delta[hiddenKey] = hidden
+		}
+	}
+
+	// The schema version always belongs to the user file, so it is not
+	// migrated a second time on the next read.
+	if version, ok := desired["schema_version"]; ok {
+		delta["schema_version"] = version
+	}
+
+	return delta
+}
+
+// subtractTable returns the keys of desired whose values differ from base,
+// and the paths of the keys only base has, which are to be hidden. t is the
+// schema type of the table; baseline keys outside the schema are never
+// hidden, since desired cannot have them.
+func subtractTable(desired, base map[string]any, t reflect.Type) (map[string]any, []any) {
+	delta := map[string]any{}
+	var removed []any
+	for key, value := range desired {
+		baseValue, ok := base[key]
+		if !ok {
+			delta[key] = value
+			continue
+		}
+
+		desiredTable, desiredOK := value.(map[string]any)
+		baseTable, baseOK := baseValue.(map[string]any)
+		if desiredOK && baseOK {
+			childType, _ := schemaChild(t, key)
+			sub, subRemoved := subtractTable(desiredTable, baseTable, childType)
+			if len(sub) > 0 {
+				delta[key] = sub
+			}
+			for _, p := range subRemoved {
+				removed = append(removed, canonicalSegment(key)+"."+p.(string))
+			}
+			continue
+		}
+
+		if !reflect.DeepEqual(value, baseValue) {
+			delta[key] = value
+		}
+	}
+
+	for key := range base {
+		if _, ok := desired[key]; ok {
+			continue
+		}
+		if _, known := schemaChild(t, key); known {
+			removed = append(removed, canonicalSegment(key))
+		}
+	}
+	slices.SortFunc(removed, func(a, b any) int { return strings.Compare(a.(string), b.(string)) })
+	return delta, removed
+}
+
+// schemaChild returns the schema type of the key of a table of type t, and
+// whether the schema has the key at all. Maps have every key.
+func schemaChild(t reflect.Type, key string) (reflect.Type, bool) {
+	if t == nil {
+		return nil, false
+	}
+	switch t = elemType(t); t.Kind() {
+	case reflect.Struct:
+		field, ok := fieldByKey(t, key)
+		return field.Type, ok
+	case reflect.Map:
+		return t.Elem(), true
+	default:
+		return nil, false
+	}
+}
+
+// tableList returns v as a list of tables, accepting both arrays of tables
+// and inline arrays holding only tables.
+func tableList(v any) ([]map[string]any, bool) {
+	switch list := v.(type) {
+	case []map[string]any:
+		return list, true
+	case []any:
+		tables := make([]map[string]any, 0, len(list))
+		for _, item := range list {
+			table, ok := item.(map[string]any)
+			if !ok {
+				return nil, false
+			}
+			tables = append(tables, table)
+		}
+		return tables, true
+	default:
+		return nil, false
+	}
+}
+
+func indexByID(entries []map[string]any, id string) int {
+	if id == "" {
+		return -1
+	}
+	for i, entry := range entries {
+		if entryID, _ := entry["id"].(string); entryID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// decodeLayered decodes the user document data laid over base into
+// settings. Without a baseline this is a plain decode.
+func decodeLayered(data []byte, base map[string]any, settings *Settings) (toml.MetaData, error) {
+	if base == nil {
+		return toml.Decode(string(data), settings)
+	}
+
+	user := map[string]any{}
+	if _, err := toml.Decode(string(data), &user); err != nil {
+		return toml.MetaData{}, err
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(mergeLayers(base, user)); err != nil {
+		return toml.MetaData{}, fmt.Errorf("failed to merge baseline: %w", err)
+	}
+	return toml.Decode(buf.String(), settings)
+}
+
+// encodeDelta encodes settings as the document to store in the user file:
+// everything without a baseline, otherwise only the difference to it.
+func encodeDelta(settings *Settings) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(settings); err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	if settings.base == nil {
+		return buf.Bytes(), nil
+	}
+
+	desired := map[string]any{}
+	if _, err := toml.Decode(buf.String(), &desired); err != nil {
+		return nil, fmt.Errorf("failed to decode encoded settings: %w", err)
+	}
+
+	buf.Reset()
+	if err := toml.NewEncoder(&buf).Encode(userDelta(desired, settings.base)); err != nil {
+		return nil, fmt.Errorf("failed to encode settings: %w", err)
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,110 @@
+package settings
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+const testBaseline = `schema_version = 2
+colour = "blue"
+
+[extension_icon_map]
+".txt" = "text.ico"
+".url" = "url.ico"
+
+[[drawers]]
+id = "b1"
+name = "Shared"
+path = "/shared"
+confined = true
+`
+
+// readLayered writes user over testBaseline and reads it.
+func readLayered(t *testing.T, user string) (string, *Settings) {
+	t.Helper()
+	path := writeSettings(t, user)
+	base := path + ".baseline"
+	if err := os.WriteFile(base, []byte(testBaseline), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvBaselinePath, base)
+
+	s, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, s
+}
+
+func TestHiddenKeys(t *testing.T) {
+	path, s := readLayered(t, "schema_version = 2\n")
+	if len(s.ExtensionIconMap) != 2 || !s.Drawers[0].Confined {
+		t.Fatalf("baseline not merged: %v, %+v", s.ExtensionIconMap, s.Drawers)
+	}
+
+	delete(s.ExtensionIconMap, ".txt")
+	s.Drawers[0].Confined = false
+	if err := Update(path, s); err != nil {
+		t.Fatal(err)
+	}
+
+	written := readFile(t, path)
+	for _, want := range []string{`hidden_keys = ["extension_icon_map.\".txt\""]`, `hidden_keys = ["confined"]`} {
+		if !strings.Contains(written, want) {
+			t.Errorf("saved file lacks %s:\n%s", want, written)
+		}
+	}
+	// Keys the schema does not know are not the user's to hide.
+	if strings.Contains(written, "colour") {
+		t.Errorf("saved file hides an unknown baseline key:\n%s", written)
+	}
+
+	again, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := again.ExtensionIconMap[".txt"]; ok || len(again.ExtensionIconMap) != 1 {
+		t.Errorf("ExtensionIconMap = %v, want .txt removed", again.ExtensionIconMap)
+	}
+	if again.Drawers[0].Confined {
+		t.Errorf("baseline drawer is confined again")
+	}
+
+	// Putting the baseline value back drops the hidden key again.
+	again.ExtensionIconMap[".txt"] = "text.ico"
+	again.Drawers[0].Confined = true
+	if err := Update(path, again); err != nil {
+		t.Fatal(err)
+	}
+	if written := readFile(t, path); strings.Contains(written, hiddenKeys) {
+		t.Errorf("hidden keys left after restoring the baseline values:\n%s", written)
+	}
+}
+
+func TestMergeLayersHiddenKeys(t *testing.T) {
+	base := map[string]any{
+		"theme":              map[string]any{"h": int64(1), "s": int64(2)},
+		"extension_icon_map": map[string]any{".txt": "text.ico", "a.b": "ab.ico"},
+	}
+	user := map[string]any{
+		"hidden_keys":        []any{`extension_icon_map."a.b"`, "theme.s", "missing.key", "not a key"},
+		"extension_icon_map": map[string]any{".md": "md.ico"},
+	}
+
+	merged := mergeLayers(base, user)
+
+	icons := merged["extension_icon_map"].(map[string]any)
+	if len(icons) != 2 || icons[".txt"] == nil || icons[".md"] == nil {
+		t.Errorf("extension_icon_map = %v, want .txt and .md", icons)
+	}
+	if theme := merged["theme"].(map[string]any); len(theme) != 1 {
+		t.Errorf("theme = %v, want only h", theme)
+	}
+	if _, ok := merged[hiddenKeys]; ok {
+		t.Errorf("hidden_keys left in the merged document")
+	}
+	if len(base["extension_icon_map"].(map[string]any)) != 2 {
+		t.Errorf("base was modified")
+	}
+}
@@ -0,0 +1,90 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// FileName is the name of the settings file in every location.
+const FileName = "goDrawer-settings.toml"
+
+// EnvConfigPath names the environment variable that points at a settings file.
+const EnvConfigPath = "GODRAWER_CONFIG"
+
+// Source identifies where the settings path came from.
+type Source int
+
+const (
+	SourceFlag Source = iota
+	SourceEnv
+	SourcePortable
+	SourceUser
+)
+
+func (s Source) String() string {
+	switch s {
+	case SourceFlag:
+		return "--config flag"
+	case SourceEnv:
+		return EnvConfigPath + " environment variable"
+	case SourcePortable:
+		return "portable file next to the executable"
+	case SourceUser:
+		return "per-user config directory"
+	default:
+		return "unknown"
+	}
+}
+
+// Location is a resolved settings path together with the source that won.
+type Location struct {
+	Path   string
+	Source Source
+}
+
+func (l Location) String() string {
+	return fmt.Sprintf("%s (from %s)", l.Path, l.Source)
+}
+
+// Locate resolves the settings path. The first match wins: flagPath, the
+// GODRAWER_CONFIG environment variable, an existing goDrawer-settings.toml
+// next to the executable (portable mode), and finally the per-user config
+// directory (%APPDATA%\goDrawer on Windows, $XDG_CONFIG_HOME/goDrawer
+// elsewhere), which is created when missing.
+func Locate(flagPath string) (Location, error) {
+	if flagPath != "" {
+		return absLocation(flagPath, SourceFlag)
+	}
+
+	if envPath := os.Getenv(EnvConfigPath); envPath != "" {
+		return absLocation(envPath, SourceEnv)
+	}
+
+	if exe, err := os.Executable(); err == nil {
+		portable := filepath.Join(filepath.Dir(exe), FileName)
+		if _, err := os.Stat(portable); err == nil {
+			return Location{Path: portable, Source: SourcePortable}, nil
+		}
+	}
+
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return Location{}, fmt.Errorf("failed to find user config directory: %w", err)
+	}
+
+	dir := filepath.Join(configDir, "goDrawer")
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return Location{}, fmt.Errorf("failed to create config directory: %w", err)
+	}
+
+	return Location{Path: filepath.Join(dir, FileName), Source: SourceUser}, nil
+}
+
+func absLocation(path string, source Source) (Location, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Location{}, fmt.Errorf("invalid settings path %q: %w", path, err)
+	}
+	return Location{Path: abs, Source: source}, nil
+}
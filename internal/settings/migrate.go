@@ -0,0 +1,228 @@
+package settings
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/BurntSushi/toml"
+)
+
+// CurrentSchemaVersion is the schema_version written by this build.
+const CurrentSchemaVersion = 2
+
+// MigrationStep records a single schema upgrade applied while reading.
+type MigrationStep struct {
+	From        int
+	To          int
+	Description string
+}
+
+// Report describes what Read had to do besides decoding the file.
+type Report struct {
+	// Created is set when the settings file did not exist and was
+	// written with defaults.
+	Created bool
+
+	Migrations []MigrationStep
+	BackupPath string
+
+	// RecoveredFrom names the backup that was loaded because the settings
+	// file itself could not be parsed; ParseError holds the reason.
+	RecoveredFrom string
+	ParseError    error
+
+	// AssignedIDs counts drawers and groups that had no usable id and
+	// received a new one.
+	AssignedIDs int
+
+	// UnknownKeys lists keys in the file that are not part of the schema
+	// and were ignored.
+	UnknownKeys []UnknownKey
+
+	// Baseline is the baseline file merged under the settings file, if
+	// any. BaselineError is set when one was found but could not be used.
+	Baseline      string
+	BaselineError error
+}
+
+// migration upgrades a raw settings document by exactly one schema version.
+type migration struct {
+	description string
+	apply       func(doc map[string]any) error
+}
+
+// migrations is indexed by the version a step upgrades from.
+var migrations = []migration{
+	0: {
+		description: "drop obsolete icon theme keys from [deprecated]",
+		apply:       migrateDropIconThemeKeys,
+	},
+	1: {
+		description: "assign stable ids to drawers",
+		apply:       migrateAssignDrawerIDs,
+	},
+}
+
+func migrateDropIconThemeKeys(doc map[string]any) error {
+	deprecated, ok := doc["deprecated"].(map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for _, key := range []string{"icon_file_theme", "icon_folder_path", "icon_unknown_theme"} {
+		delete(deprecated, key)
+	}
+	if len(deprecated) == 0 {
+		delete(doc, "deprecated")
+	}
+
+	return nil
+}
+
+func migrateAssignDrawerIDs(doc map[string]any) error {
+	drawers, ok := doc["drawers"].([]map[string]any)
+	if !ok {
+		return nil
+	}
+
+	for _, drawer := range drawers {
+		if id, _ := drawer["id"].(string); id == "" {
+			drawer["id"] = NewDrawerID()
+		}
+	}
+
+	return nil
+}
+
+// schemaVersion extracts schema_version from a raw document. Files written
+// before the key existed are version 0.
+func schemaVersion(doc map[string]any) (int, error) {
+	raw, ok := doc["schema_version"]
+	if !ok {
+		return 0, nil
+	}
+
+	version, ok := raw.(int64)
+	if !ok || version < 0 {
+		return 0, fmt.Errorf("invalid schema_version %v", raw)
+	}
+
+	return int(version), nil
+}
+
+// migrate upgrades doc in place to CurrentSchemaVersion, one step at a time,
+// and records every applied step in report.
+func migrate(doc map[string]any, report *Report) error {
+	version, err := schemaVersion(doc)
+	if err != nil {
+		return err
+	}
+	if version > CurrentSchemaVersion {
+		return fmt.Errorf("settings schema version %d is newer than supported version %d", version, CurrentSchemaVersion)
+	}
+
+	for ; version < CurrentSchemaVersion; version++ {
+		step := migrations[version]
+		if err := step.apply(doc); err != nil {
+			return fmt.Errorf("migration v%d -> v%d failed: %w", version, version+1, err)
+		}
+
+		doc["schema_version"] = int64(version + 1)
+		report.Migrations = append(report.Migrations, MigrationStep{
+			From:        version,
+			To:          version + 1,
+			Description: step.description,
+		})
+	}
+
+	return nil
+}
+
+// migrateData decodes data, runs pending migrations and returns the
+// re-encoded document. When no migration was needed data is returned as is.
+func migrateData(data []byte, report *Report) ([]byte, error) {
+	doc := map[string]any{}
+	if _, err := toml.Decode(string(data), &doc); err != nil {
+		return nil, &decodeError{err}
+	}
+
+	if err := migrate(doc, report); err != nil {
+		return nil, err
+	}
+	if len(report.Migrations) == 0 {
+		return data, nil
+	}
+
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(doc); err != nil {
+		return nil, fmt.Errorf("failed to encode migrated settings: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// backupBeforeMigration keeps the pre-migration file as <path>.v<N>.bak.
+func backupBeforeMigration(path string, data []byte, from int) (string, error) {
+	backup := fmt.Sprintf("%s.v%d.bak", path, from)
+	if err := os.WriteFile(backup, data, 0o644); err != nil {
+		return "", fmt.Errorf("failed to back up settings before migration: %w", err)
+	}
+	return backup, nil
+}
+
+// PrintReport prints what Read had to repair or upgrade, if anything.
+func PrintReport(report *Report) {
+	FprintReport(os.Stdout, report)
+}
+
+// FprintReport is PrintReport writing to w.
+func FprintReport(w io.Writer, report *Report) {
+	if report == nil {
+		return
+	}
+
+	if report.Created {
+		fmt.Fprintln(w, "Settings file created successfully.")
+		fmt.Fprintln(w)
+	}
+	if report.Baseline != "" {
+		fmt.Fprintf(w, ":: Settings Baseline ::\n  Merged over %s\n\n", report.Baseline)
+	}
+	if report.BaselineError != nil {
+		fmt.Fprintf(w, ":: Settings Baseline ::\n  Ignored: %v\n\n", report.BaselineError)
+	}
+
+	if report.RecoveredFrom != "" {
+		fmt.Fprintln(w, ":: Settings Recovery ::")
+		fmt.Fprintf(w, "  Settings file was unreadable: %v\n", report.ParseError)
+		fmt.Fprintf(w, "  Restored from %s\n", report.RecoveredFrom)
+		fmt.Fprintln(w)
+	}
+
+	if len(report.UnknownKeys) > 0 {
+		fmt.Fprintln(w, ":: Unknown Settings Keys ::")
+		for _, key := range report.UnknownKeys {
+			fmt.Fprintf(w, "  %s\n", key)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if report.AssignedIDs > 0 {
+		fmt.Fprintf(w, ":: Assigned ids to %d drawers and groups ::\n\n", report.AssignedIDs)
+	}
+
+	if len(report.Migrations) == 0 {
+		return
+	}
+
+	fmt.Fprintln(w, ":: Settings Migrations ::")
+	for _, step := range report.Migrations {
+		fmt.Fprintf(w, "  v%d -> v%d: %s\n", step.From, step.To, step.Description)
+	}
+	if report.BackupPath != "" {
+		fmt.Fprintf(w, "  Original saved to %s\n", report.BackupPath)
+	}
+	fmt.Fprintln(w)
+}
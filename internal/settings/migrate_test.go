@@ -0,0 +1,136 @@
+package settings
+
+import (
+	"strings"
+	"testing"
+)
+
+const v0Settings = `# hand-written before schema_version existed
+[startup]
+start_with_windows = false
+window_locked = false
+
+[[drawers]]
+name = "Docs"
+path = "/docs"
+
+[deprecated]
+icon_file_theme = "dark"
+icon_folder_path = "icons"
+keep = "yes"
+`
+
+func TestMigrate(t *testing.T) {
+	tests := []struct {
+		name    string
+		doc     map[string]any
+		want    []MigrationStep
+		wantErr string
+	}{
+		{
+			name: "from v0",
+			doc:  map[string]any{},
+			want: []MigrationStep{
+				{From: 0, To: 1, Description: migrations[0].description},
+				{From: 1, To: 2, Description: migrations[1].description},
+			},
+		},
+		{
+			name: "from v1",
+			doc:  map[string]any{"schema_version": int64(1)},
+			want: []MigrationStep{{From: 1, To: 2, Description: migrations[1].description}},
+		},
+		{
+			name: "current",
+			doc:  map[string]any{"schema_version": int64(CurrentSchemaVersion)},
+		},
+		{
+			name:    "newer",
+			doc:     map[string]any{"schema_version": int64(CurrentSchemaVersion + 1)},
+			wantErr: "newer than supported",
+		},
+		{
+			name:    "negative",
+			doc:     map[string]any{"schema_version": int64(-1)},
+			wantErr: "invalid schema_version",
+		},
+		{
+			name:    "not a number",
+			doc:     map[string]any{"schema_version": "2"},
+			wantErr: "invalid schema_version",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var report Report
+			err := migrate(tt.doc, &report)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("migrate = %v, want an error containing %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("migrate: %v", err)
+			}
+
+			if len(report.Migrations) != len(tt.want) {
+				t.Fatalf("Migrations = %+v, want %+v", report.Migrations, tt.want)
+			}
+			for i := range tt.want {
+				if report.Migrations[i] != tt.want[i] {
+					t.Errorf("Migrations[%d] = %+v, want %+v", i, report.Migrations[i], tt.want[i])
+				}
+			}
+			if v := tt.doc["schema_version"]; v != int64(CurrentSchemaVersion) {
+				t.Errorf("schema_version = %v, want %d", v, CurrentSchemaVersion)
+			}
+		})
+	}
+}
+
+func TestReadMigratesFromV0(t *testing.T) {
+	path := writeSettings(t, v0Settings)
+
+	s, report, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+
+	if s.SchemaVersion != CurrentSchemaVersion || len(report.Migrations) != CurrentSchemaVersion {
+		t.Errorf("SchemaVersion = %d after %d migrations, want %d", s.SchemaVersion, len(report.Migrations), CurrentSchemaVersion)
+	}
+	if len(s.Drawers) != 1 || s.Drawers[0].ID == "" {
+		t.Errorf("Drawers = %+v, want one drawer with an id", s.Drawers)
+	}
+	want := map[string]string{"keep": "yes"}
+	if len(s.Deprecated) != len(want) || s.Deprecated["keep"] != "yes" {
+		t.Errorf("Deprecated = %v, want %v", s.Deprecated, want)
+	}
+
+	// The original is kept before the migrated file is written.
+	if report.BackupPath != path+".v0.bak" {
+		t.Errorf("BackupPath = %q, want %q", report.BackupPath, path+".v0.bak")
+	}
+	if got := readFile(t, report.BackupPath); got != v0Settings {
+		t.Errorf("backup = %q, want the original file", got)
+	}
+
+	written := readFile(t, path)
+	if !strings.Contains(written, "schema_version = 2") || !strings.Contains(written, s.Drawers[0].ID) {
+		t.Errorf("migrated file was not written back:\n%s", written)
+	}
+	if !strings.Contains(written, "# hand-written before schema_version existed\n[startup]") {
+		t.Errorf("migrated file lost its comment:\n%s", written)
+	}
+
+	// Reading again finds nothing left to do.
+	again, report, err := Read(path)
+	if err != nil {
+		t.Fatalf("second Read: %v", err)
+	}
+	if len(report.Migrations) != 0 || again.Drawers[0].ID != s.Drawers[0].ID {
+		t.Errorf("second Read migrated %v and gave id %q, want nothing and %q", report.Migrations, again.Drawers[0].ID, s.Drawers[0].ID)
+	}
+}
@@ -0,0 +1,227 @@
+package settings
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// FolderTokens lists the known-folder tokens that may appear in paths as
+// {Name}, e.g. {Desktop}\Projects.
+var FolderTokens = []string{
+	"Home",
+	"Desktop",
+	"Documents",
+	"Downloads",
+	"Pictures",
+	"Music",
+	"Videos",
+	"AppData",
+	"LocalAppData",
+	"ProgramData",
+	"ProgramFiles",
+	"StartMenu",
+	"Programs",
+	"Startup",
+}
+
+var (
+	folderToken = regexp.MustCompile(`\{([A-Za-z]+)\}`)
+	percentVar  = regexp.MustCompile(`%([A-Za-z_][A-Za-z0-9_()]*)%`)
+	dollarVar   = regexp.MustCompile(`\$\{([A-Za-z_][A-Za-z0-9_]*)\}|\$([A-Za-z_][A-Za-z0-9_]*)`)
+)
+
+// ExpandPath turns a path as written in the settings file into a usable
+// one. In order it replaces known-folder tokens such as {Desktop},
+// %VAR% and $VAR or ${VAR} environment variables, and a leading ~ with the
+// home directory. A path that is still relative is taken relative to dir,
+// the directory of the settings file. Tokens and variables that cannot be
+// resolved are left as they are.
+func ExpandPath(path, dir string) string {
+	if path == "" {
+		return ""
+	}
+
+	expanded := folderToken.ReplaceAllStringFunc(path, func(match string) string {
+		if folder, ok := knownFolder(match[1 : len(match)-1]); ok {
+			return folder
+		}
+		return match
+	})
+
+	expanded = percentVar.ReplaceAllStringFunc(expanded, func(match string) string {
+		if value, ok := os.LookupEnv(match[1 : len(match)-1]); ok {
+			return value
+		}
+		return match
+	})
+
+	expanded = dollarVar.ReplaceAllStringFunc(expanded, func(match string) string {
+		name := strings.Trim(match, "${}")
+		if value, ok := os.LookupEnv(name); ok {
+			return value
+		}
+		return match
+	})
+
+	if expanded == "~" || strings.HasPrefix(expanded, "~/") || strings.HasPrefix(expanded, `~\`) {
+		if home, err := os.UserHomeDir(); err == nil {
+			expanded = home + expanded[1:]
+		}
+	}
+
+	if dir != "" && !isRooted(expanded) {
+		expanded = filepath.Join(dir, expanded)
+	}
+
+	return filepath.Clean(expanded)
+}
+
+// isRooted reports whether path is absolute or starts at the root of the
+// current drive, which filepath.IsAbs does not count on Windows.
+func isRooted(path string) bool {
+	return filepath.IsAbs(path) || filepath.VolumeName(path) != "" ||
+		strings.HasPrefix(path, `\`) || strings.HasPrefix(path, "/")
+}
+
+// ContractPath is the reverse of ExpandPath for folders picked in the UI:
+// a path inside a known folder is written with its token, and one inside
+// the home directory with ~, so the setting works for other users too.
+func ContractPath(path string) string {
+	clean := filepath.Clean(path)
+
+	best, bestLen := "", 0
+	for _, token := range FolderTokens {
+		folder, ok := knownFolder(token)
+		if !ok || len(folder) <= bestLen {
+			continue
+		}
+		if rest, ok := cutPathPrefix(clean, folder); ok {
+			best, bestLen = "{"+token+"}"+rest, len(folder)
+		}
+	}
+	if best != "" {
+		// Home is reported as ~ rather than {Home}.
+		if strings.HasPrefix(best, "{Home}") {
+			return "~" + strings.TrimPrefix(best, "{Home}")
+		}
+		return best
+	}
+
+	return path
+}
+
+// cutPathPrefix returns the part of path after folder, including the
+// leading separator, when path lies inside folder.
+func cutPathPrefix(path, folder string) (string, bool) {
+	folder = filepath.Clean(folder)
+	if pathKey(path) == pathKey(folder) {
+		return "", true
+	}
+
+	prefix := strings.TrimRight(folder, `\/`) + string(filepath.Separator)
+	if len(path) > len(prefix) && pathKey(path[:len(prefix)]) == pathKey(prefix) {
+		return path[len(prefix)-1:], true
+	}
+	return "", false
+}
+
+// checkPathTokens reports the first {Token} in path that is not a known
+// folder. Like ExpandPath it only takes letters in braces for a token, and
+// a path that exists as written is fine: its braces are part of a name,
+// as in {backup}.
+func (s *Settings) checkPathTokens(path string) error {
+	for _, match := range folderToken.FindAllStringSubmatch(path, -1) {
+		if isFolderToken(match[1]) {
+			continue
+		}
+		if _, err := os.Stat(s.ResolvePath(path)); err == nil {
+			return nil
+		}
+		return fmt.Errorf("unknown folder token %s", match[0])
+	}
+	return nil
+}
+
+func isFolderToken(name string) bool {
+	for _, token := range FolderTokens {
+		if token == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ResolvePath expands path, relative paths being resolved against the
+// directory of the settings file these settings were read from.
+func (s *Settings) ResolvePath(path string) string {
+	return ExpandPath(path, s.dir)
+}
+
+// Dir returns the directory of the settings file, or "" for settings that
+// were not read from a file.
+func (s *Settings) Dir() string {
+	return s.dir
+}
+
+// SuggestPath guesses what a path that does not exist was meant to be:
+// each missing segment is replaced by the one entry of its parent folder
+// with a close enough name, so asset\icons\url.png becomes
+// assets\icons\url.png. A relative path stays relative. It returns false
+// when the path exists or no single candidate is found.
+func (s *Settings) SuggestPath(path string) (string, bool) {
+	resolved := s.ResolvePath(path)
+	if _, err := os.Stat(resolved); err == nil || !filepath.IsAbs(resolved) {
+		return "", false
+	}
+
+	volume := filepath.VolumeName(resolved)
+	current := volume + string(filepath.Separator)
+	for _, segment := range strings.Split(strings.TrimPrefix(resolved[len(volume):], string(filepath.Separator)), string(filepath.Separator)) {
+		next := filepath.Join(current, segment)
+		if _, err := os.Stat(next); err == nil {
+			current = next
+			continue
+		}
+
+		match, ok := closestEntry(current, segment)
+		if !ok {
+			return "", false
+		}
+		current = filepath.Join(current, match)
+	}
+
+	if !isRooted(path) && !strings.ContainsAny(path, "{%$~") && s.dir != "" {
+		if rel, err := filepath.Rel(s.dir, current); err == nil {
+			return rel, true
+		}
+	}
+	return ContractPath(current), true
+}
+
+// closestEntry returns the single entry of dir whose name is within a few
+// edits of name.
+func closestEntry(dir, name string) (string, bool) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return "", false
+	}
+
+	best, bestDistance, ties := "", -1, 0
+	for _, entry := range entries {
+		distance := levenshtein(strings.ToLower(name), strings.ToLower(entry.Name()))
+		switch {
+		case bestDistance < 0 || distance < bestDistance:
+			best, bestDistance, ties = entry.Name(), distance, 1
+		case distance == bestDistance:
+			ties++
+		}
+	}
+
+	if bestDistance < 0 || ties > 1 || bestDistance > max(1, len(name)/3) {
+		return "", false
+	}
+	return best, true
+}
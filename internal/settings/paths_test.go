@@ -0,0 +1,37 @@
+package settings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckPathTokens(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "{backup}"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	s := &Settings{dir: dir}
+
+	tests := []struct {
+		path  string
+		valid bool
+	}{
+		{"{Desktop}/Projects", true},
+		{"C:/plain/path", true},
+		{"{3F2504E0-4F89-11D3-9A0C-0305E82C3301}/data", true},
+		{"{old backup}/data", true},
+		{"{backup}", true},
+		{filepath.Join(dir, "{backup}"), true},
+		{"{Desktp}/Projects", false},
+		{"{missing}", false},
+		{"{Desktop}/{Nope}", false},
+	}
+
+	for _, tt := range tests {
+		err := s.checkPathTokens(tt.path)
+		if (err == nil) != tt.valid {
+			t.Errorf("checkPathTokens(%q) = %v, want valid %v", tt.path, err, tt.valid)
+		}
+	}
+}
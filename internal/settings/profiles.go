@@ -0,0 +1,191 @@
+package settings
+
+import (
+	"maps"
+	"strings"
+)
+
+// BaseProfileName is the display name of the shared base, which is active
+// when no profile is selected.
+const BaseProfileName = "Default"
+
+// Profile is a named drawer set with its own look. Everything a profile
+// does not override is inherited from the shared base: drawers without a
+// profile are shown in every profile, a missing theme falls back to the
+// base theme and the icon map is merged over the base map.
+type Profile struct {
+	ID               string            `toml:"id"`
+	Name             string            `toml:"name"`
+	Theme            *Theme            `toml:"theme,omitempty"`
+	ExtensionIconMap map[string]string `toml:"extension_icon_map,omitempty"`
+}
+
+// ProfileIndex returns the index of the profile with the given ID, or -1.
+func (s *Settings) ProfileIndex(id string) int {
+	for i := range s.Profiles {
+		if s.Profiles[i].ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// Profile returns the active profile, or nil when the shared base is
+// active or ActiveProfile names a profile that does not exist.
+func (s *Settings) Profile() *Profile {
+	if i := s.ProfileIndex(s.ActiveProfile); s.ActiveProfile != "" && i >= 0 {
+		return &s.Profiles[i]
+	}
+	return nil
+}
+
+// ActiveTheme returns the theme of the active profile, or the base theme
+// when the profile does not override it.
+func (s *Settings) ActiveTheme() Theme {
+	return s.ProfileTheme(s.ActiveProfileID())
+}
+
+// ProfileTheme returns the theme the profile with the given ID shows: its
+// own, or the base theme. The empty ID stands for the shared base.
+func (s *Settings) ProfileTheme(id string) Theme {
+	if i := s.ProfileIndex(id); id != "" && i >= 0 && s.Profiles[i].Theme != nil {
+		return *s.Profiles[i].Theme
+	}
+	return s.Theme
+}
+
+// SetActiveTheme stores theme where ActiveTheme reads it from: in the active
+// profile, or in the base when no profile is active.
+func (s *Settings) SetActiveTheme(theme Theme) {
+	if p := s.Profile(); p != nil {
+		p.Theme = &theme
+		return
+	}
+	s.Theme = theme
+}
+
+// ActiveIconMap returns the base extension icon map with the active
+// profile's entries applied on top. Icon paths are returned expanded.
+func (s *Settings) ActiveIconMap() map[string]string {
+	merged := maps.Clone(s.ExtensionIconMap)
+	if merged == nil {
+		merged = map[string]string{}
+	}
+	if p := s.Profile(); p != nil {
+		maps.Copy(merged, p.ExtensionIconMap)
+	}
+	for ext, icon := range merged {
+		merged[ext] = s.ResolvePath(icon)
+	}
+	return merged
+}
+
+// DrawerVisible reports whether drawer belongs to the active profile or to
+// the shared base.
+func (s *Settings) DrawerVisible(drawer Drawer) bool {
+	return drawer.Profile == "" || drawer.Profile == s.ActiveProfileID()
+}
+
+// ActiveProfileID returns the ID of the active profile, or "" for the
+// shared base.
+func (s *Settings) ActiveProfileID() string {
+	if p := s.Profile(); p != nil {
+		return p.ID
+	}
+	return ""
+}
+
+// SetActiveProfile switches to the profile with the given ID. The empty ID
+// selects the shared base.
+func (s *Settings) SetActiveProfile(id string) error {
+	if id != "" && s.ProfileIndex(id) < 0 {
+		return ErrNotFound
+	}
+	s.ActiveProfile = id
+	return nil
+}
+
+// AddProfile appends a new profile that inherits everything from the base
+// and returns it.
+func (s *Settings) AddProfile(name string) (Profile, error) {
+	name = strings.TrimSpace(name)
+	if err := s.checkProfileName("", name); err != nil {
+		return Profile{}, err
+	}
+
+	profile := Profile{ID: NewProfileID(), Name: name}
+	s.Profiles = append(s.Profiles, profile)
+	return profile, nil
+}
+
+// RenameProfile changes the name of a profile.
+func (s *Settings) RenameProfile(id, name string) error {
+	i := s.ProfileIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	name = strings.TrimSpace(name)
+	if err := s.checkProfileName(id, name); err != nil {
+		return err
+	}
+
+	s.Profiles[i].Name = name
+	return nil
+}
+
+// RemoveProfile deletes a profile together with the drawers that belong
+// only to it. Removing the active profile activates the shared base.
+func (s *Settings) RemoveProfile(id string) error {
+	i := s.ProfileIndex(id)
+	if i < 0 {
+		return ErrNotFound
+	}
+
+	s.Profiles = append(s.Profiles[:i], s.Profiles[i+1:]...)
+
+	drawers := s.Drawers[:0]
+	for _, drawer := range s.Drawers {
+		if drawer.Profile != id {
+			drawers = append(drawers, drawer)
+		}
+	}
+	s.Drawers = drawers
+
+	if s.ActiveProfile == id {
+		s.ActiveProfile = ""
+	}
+	return nil
+}
+
+// ProfileDrawerCount returns how many drawers belong only to the profile.
+func (s *Settings) ProfileDrawerCount(id string) int {
+	count := 0
+	for _, drawer := range s.Drawers {
+		if drawer.Profile == id {
+			count++
+		}
+	}
+	return count
+}
+
+func (s *Settings) checkProfileName(id, name string) error {
+	if name == "" {
+		return ErrEmptyName
+	}
+	if strings.EqualFold(name, BaseProfileName) {
+		return ErrDuplicateName
+	}
+	for _, profile := range s.Profiles {
+		if profile.ID != id && strings.EqualFold(strings.TrimSpace(profile.Name), name) {
+			return ErrDuplicateName
+		}
+	}
+	return nil
+}
+
+// sharesProfile reports whether drawers of profiles a and b can be shown at
+// the same time, which is when either is shared or both are the same.
+func sharesProfile(a, b string) bool {
+	return a == "" || b == "" || a == b
+}
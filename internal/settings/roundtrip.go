@@ -0,0 +1,695 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// The round-trip writer keeps hand-edited settings files intact. Instead of
+// replacing the file with a freshly encoded document, patchDocument compares
+// the existing text with the encoded one key by key and only touches lines
+// whose values actually changed. Comments, blank lines, key order and
+// formatting of everything else survive a save, as do keys outside the
+// schema. Defaults the user never wrote are not added.
+
+// docKey is a key/value pair of a parsed document.
+type docKey struct {
+	path  string // canonical full path, e.g. drawers[2].size.width
+	table string // canonical path of the enclosing table header
+	first int    // line of the key
+	last  int    // last line of the value, for multi-line values
+	value string // value text, without trailing comment
+	// prefix is the text before the value on the first line and suffix the
+	// text after it on the last line (whitespace and comment).
+	prefix string
+	suffix string
+}
+
+// docTable is a table header of a parsed document and the lines it owns.
+// The root table has header -1.
+type docTable struct {
+	path   string
+	header int
+	// end is the last line holding content of this table, not counting the
+	// blank and comment lines that lead into the next header.
+	end  int
+	keys []*docKey
+}
+
+type document struct {
+	lines  []string
+	crlf   bool
+	tables []*docTable
+	keys   []*docKey
+}
+
+func (d *document) table(path string) *docTable {
+	for _, t := range d.tables {
+		if t.path == path {
+			return t
+		}
+	}
+	return nil
+}
+
+func (d *document) key(path string) *docKey {
+	for _, k := range d.keys {
+		if k.path == path {
+			return k
+		}
+	}
+	return nil
+}
+
+// lastKeyUnder returns the last key whose path lies below prefix.
+func (d *document) lastKeyUnder(prefix string) *docKey {
+	var found *docKey
+	for _, k := range d.keys {
+		if prefix == "" || strings.HasPrefix(k.path, prefix+".") {
+			if found == nil || k.last > found.last {
+				found = k
+			}
+		}
+	}
+	return found
+}
+
+// siblingFor returns the last key that new keys of the given table should
+// follow: a key directly in that table, or a dotted key defining it from an
+// enclosing table.
+func (d *document) siblingFor(table string) *docKey {
+	var found *docKey
+	for _, k := range d.keys {
+		direct := k.table == table
+		dotted := table != "" && k.table != table && strings.HasPrefix(k.path, table+".") &&
+			(k.table == "" || strings.HasPrefix(table, k.table+"."))
+		if (direct || dotted) && (found == nil || k.last > found.last) {
+			found = k
+		}
+	}
+	return found
+}
+
+// inlineTable renders every key below path as a single-line inline table.
+func (d *document) inlineTable(path string) (string, bool) {
+	var pairs []string
+	for _, k := range d.keys {
+		if !strings.HasPrefix(k.path, path+".") {
+			continue
+		}
+		rel := strings.TrimPrefix(k.path, path+".")
+		if k.first != k.last || strings.Contains(rel, "[") {
+			return "", false
+		}
+		pairs = append(pairs, rel+" = "+k.value)
+	}
+	if len(pairs) == 0 {
+		return "", false
+	}
+	return "{ " + strings.Join(pairs, ", ") + " }", true
+}
+
+func parseDocument(data []byte) (*document, error) {
+	text := string(data)
+	doc := &document{crlf: strings.Contains(text, "\r\n")}
+	doc.lines = strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	arrays := map[string]int{}
+	current := &docTable{path: "", header: -1, end: -1}
+	doc.tables = append(doc.tables, current)
+
+	for i := 0; i < len(doc.lines); i++ {
+		trimmed := strings.TrimSpace(doc.lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "[") {
+			isArray := strings.HasPrefix(trimmed, "[[")
+			open := 1
+			if isArray {
+				open = 2
+			}
+
+			parts, next, err := parseKey(trimmed, open)
+			if err != nil {
+				return nil, fmt.Errorf("line %d: %w", i+1, err)
+			}
+			closing := strings.Repeat("]", open)
+			if !strings.HasPrefix(trimmed[next:], closing) {
+				return nil, fmt.Errorf("line %d: malformed table header", i+1)
+			}
+
+			path := resolveTablePath(parts, arrays, isArray)
+			current = &docTable{path: path, header: i, end: i}
+			doc.tables = append(doc.tables, current)
+			continue
+		}
+
+		line := doc.lines[i]
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		parts, next, err := parseKey(line, indent)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		next = skipSpace(line, next)
+		if next >= len(line) || line[next] != '=' {
+			return nil, fmt.Errorf("line %d: expected '=' after key", i+1)
+		}
+		start := skipSpace(line, next+1)
+
+		lastLine, end, err := scanValue(doc.lines, i, start)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+
+		var value string
+		if lastLine == i {
+			value = line[start:end]
+		} else {
+			value = strings.Join(append([]string{line[start:]}, append(doc.lines[i+1:lastLine], doc.lines[lastLine][:end])...), "\n")
+		}
+
+		key := &docKey{
+			path:   joinPath(current.path, canonicalKey(parts)),
+			table:  current.path,
+			first:  i,
+			last:   lastLine,
+			value:  value,
+			prefix: line[:start],
+			suffix: doc.lines[lastLine][end:],
+		}
+		current.keys = append(current.keys, key)
+		current.end = lastLine
+		doc.keys = append(doc.keys, key)
+		i = lastLine
+	}
+
+	return doc, nil
+}
+
+// resolveTablePath turns the parts of a table header into a canonical path,
+// indexing every array of tables it passes through by its latest element.
+func resolveTablePath(parts []string, arrays map[string]int, isArray bool) string {
+	path := ""
+	for i, part := range parts {
+		path = joinPath(path, canonicalSegment(part))
+		if i == len(parts)-1 {
+			break
+		}
+		if count, ok := arrays[path]; ok {
+			path = fmt.Sprintf("%s[%d]", path, count-1)
+		}
+	}
+
+	if !isArray {
+		return path
+	}
+
+	index := arrays[path]
+	arrays[path] = index + 1
+	return fmt.Sprintf("%s[%d]", path, index)
+}
+
+// parseKey reads a possibly dotted, possibly quoted key starting at s[i].
+func parseKey(s string, i int) ([]string, int, error) {
+	var parts []string
+	for {
+		i = skipSpace(s, i)
+		if i >= len(s) {
+			return nil, i, errors.New("unexpected end of key")
+		}
+
+		switch s[i] {
+		case '"':
+			end := i + 1
+			for end < len(s) && s[end] != '"' {
+				if s[end] == '\\' {
+					end++
+				}
+				end++
+			}
+			if end >= len(s) {
+				return nil, i, errors.New("unterminated quoted key")
+			}
+			part, err := strconv.Unquote(s[i : end+1])
+			if err != nil {
+				return nil, i, fmt.Errorf("invalid quoted key: %w", err)
+			}
+			parts = append(parts, part)
+			i = end + 1
+		case '\'':
+			end := strings.IndexByte(s[i+1:], '\'')
+			if end < 0 {
+				return nil, i, errors.New("unterminated quoted key")
+			}
+			parts = append(parts, s[i+1:i+1+end])
+			i += end + 2
+		default:
+			start := i
+			for i < len(s) && isBareKeyChar(s[i]) {
+				i++
+			}
+			if start == i {
+				return nil, i, fmt.Errorf("unexpected character %q in key", s[i])
+			}
+			parts = append(parts, s[start:i])
+		}
+
+		next := skipSpace(s, i)
+		if next < len(s) && s[next] == '.' {
+			i = next + 1
+			continue
+		}
+		return parts, i, nil
+	}
+}
+
+// scanValue finds the end of the value starting at lines[line][col]. It
+// returns the last line of the value and the offset just after it there.
+func scanValue(lines []string, line, col int) (int, int, error) {
+	depth := 0
+	end := col
+
+	for line < len(lines) {
+		s := lines[line]
+		i := col
+		for i < len(s) {
+			switch c := s[i]; {
+			case strings.HasPrefix(s[i:], `"""`) || strings.HasPrefix(s[i:], `'''`):
+				delim := s[i : i+3]
+				l, e, err := scanMultiline(lines, line, i+3, delim)
+				if err != nil {
+					return 0, 0, err
+				}
+				line, s, i = l, lines[l], e
+				end = i
+				continue
+			case c == '"':
+				j := i + 1
+				for j < len(s) && s[j] != '"' {
+					if s[j] == '\\' {
+						j++
+					}
+					j++
+				}
+				if j >= len(s) {
+					return 0, 0, errors.New("unterminated string")
+				}
+				i = j + 1
+				end = i
+				continue
+			case c == '\'':
+				j := strings.IndexByte(s[i+1:], '\'')
+				if j < 0 {
+					return 0, 0, errors.New("unterminated string")
+				}
+				i += j + 2
+				end = i
+				continue
+			case c == '[' || c == '{':
+				depth++
+			case c == ']' || c == '}':
+				depth--
+			case c == '#':
+				if depth == 0 {
+					return line, end, nil
+				}
+				i = len(s)
+				continue
+			case c == ' ' || c == '\t':
+				i++
+				continue
+			}
+			i++
+			end = i
+		}
+
+		if depth <= 0 {
+			return line, end, nil
+		}
+		line++
+		col = 0
+		end = 0
+	}
+
+	return 0, 0, errors.New("unterminated value")
+}
+
+func scanMultiline(lines []string, line, col int, delim string) (int, int, error) {
+	for ; line < len(lines); line, col = line+1, 0 {
+		s := lines[line]
+		for i := col; i < len(s); i++ {
+			if delim == `"""` && s[i] == '\\' {
+				i++
+				continue
+			}
+			if strings.HasPrefix(s[i:], delim) {
+				end := i + 3
+				// Up to two quotes directly before the delimiter belong to
+				// the string.
+				for n := 0; n < 2 && end < len(s) && s[end] == delim[0]; n++ {
+					end++
+				}
+				return line, end, nil
+			}
+		}
+	}
+	return 0, 0, errors.New("unterminated multi-line string")
+}
+
+func skipSpace(s string, i int) int {
+	for i < len(s) && (s[i] == ' ' || s[i] == '\t') {
+		i++
+	}
+	return i
+}
+
+func isBareKeyChar(c byte) bool {
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '_' || c == '-'
+}
+
+func canonicalSegment(part string) string {
+	for i := 0; i < len(part); i++ {
+		if !isBareKeyChar(part[i]) {
+			return strconv.Quote(part)
+		}
+	}
+	if part == "" {
+		return `""`
+	}
+	return part
+}
+
+func canonicalKey(parts []string) string {
+	segments := make([]string, len(parts))
+	for i, part := range parts {
+		segments[i] = canonicalSegment(part)
+	}
+	return strings.Join(segments, ".")
+}
+
+func joinPath(parent, child string) string {
+	if parent == "" {
+		return child
+	}
+	return parent + "." + child
+}
+
+// sameValue compares two TOML value texts semantically.
+func sameValue(a, b string) bool {
+	if a == b {
+		return true
+	}
+
+	var lhs, rhs map[string]any
+	if _, err := toml.Decode("v = "+a, &lhs); err != nil {
+		return false
+	}
+	if _, err := toml.Decode("v = "+b, &rhs); err != nil {
+		return false
+	}
+	return reflect.DeepEqual(lhs, rhs)
+}
+
+// savedDocument is what a settings file amounts to when saving over it.
+type savedDocument struct {
+	// implied is the document Update would write for the settings the file
+	// decodes to: keys left out of the file show up with their defaults.
+	implied *document
+	// unknown holds the keys, without array indexes, that do not belong to
+	// the schema.
+	unknown map[string]bool
+}
+
+// readSaved decodes data laid over base the way Read does.
+func readSaved(data []byte, base map[string]any) (*savedDocument, error) {
+	settings := Settings{base: base}
+	md, err := decodeLayered(data, base, &settings)
+	if err != nil {
+		return nil, err
+	}
+	settings.applyDefaults()
+
+	encoded, err := encodeDelta(&settings)
+	if err != nil {
+		return nil, err
+	}
+	implied, err := parseDocument(encoded)
+	if err != nil {
+		return nil, err
+	}
+
+	unknown := map[string]bool{}
+	for _, key := range md.Undecoded() {
+		unknown[canonicalKey(key)] = true
+	}
+	return &savedDocument{implied: implied, unknown: unknown}, nil
+}
+
+// known reports whether the key or table at path belongs to the schema.
+func (d *savedDocument) known(path string) bool {
+	path = arrayIndex.ReplaceAllString(path, "")
+	for {
+		if d.unknown[path] {
+			return false
+		}
+		i := strings.LastIndexByte(path, '.')
+		if i < 0 {
+			return true
+		}
+		path = path[:i]
+	}
+}
+
+// implies reports whether the file already amounts to the key k of the
+// desired document, so that writing it would change nothing. The ids of
+// drawers, groups and profiles are always written, since entries laid over
+// a baseline are matched by them.
+func (d *savedDocument) implies(k *docKey) bool {
+	if list, ok := strings.CutSuffix(arrayIndex.ReplaceAllString(k.path, ""), ".id"); ok {
+		if _, layered := layeredLists[list]; layered {
+			return false
+		}
+	}
+	implied := d.implied.key(k.path)
+	return implied != nil && sameValue(implied.value, k.value)
+}
+
+// patchDocument rewrites old so that it reads as the same settings as
+// desired, laid over base, while changing as few lines as possible. Keys
+// outside the schema are left alone, as are keys the user left out and
+// whose defaults still apply. It returns an error when old cannot be
+// parsed or the patched result does not read as desired; callers then fall
+// back to writing desired as is.
+func patchDocument(old, desired []byte, base map[string]any) ([]byte, error) {
+	od, err := parseDocument(old)
+	if err != nil {
+		return nil, err
+	}
+	nd, err := parseDocument(desired)
+	if err != nil {
+		return nil, err
+	}
+	saved, err := readSaved(old, base)
+	if err != nil {
+		return nil, err
+	}
+
+	replace := map[int]string{}
+	deleted := map[int]bool{}
+	insertAfter := map[int][]string{}
+
+	deleteLines := func(first, last int) {
+		for i := first; i <= last; i++ {
+			deleted[i] = true
+		}
+	}
+
+	// Inline tables written by hand stay inline; their new contents are
+	// rendered inline instead of as separate tables.
+	inline := map[string]string{}
+	for _, k := range od.keys {
+		if nd.key(k.path) != nil || nd.lastKeyUnder(k.path) == nil {
+			continue
+		}
+		if value, ok := nd.inlineTable(k.path); ok {
+			inline[k.path] = value
+		}
+	}
+	inlined := func(path string) bool {
+		for prefix := range inline {
+			if path == prefix || strings.HasPrefix(path, prefix+".") {
+				return true
+			}
+		}
+		return false
+	}
+
+	// Tables that disappeared are removed with their keys.
+	for _, t := range od.tables {
+		if t.header < 0 || !saved.known(t.path) || nd.table(t.path) != nil || nd.lastKeyUnder(t.path) != nil {
+			continue
+		}
+		if saved.implied.table(t.path) == nil && saved.implied.lastKeyUnder(t.path) == nil {
+			continue
+		}
+		deleteLines(t.header, t.end)
+		if t.header > 0 && strings.TrimSpace(od.lines[t.header-1]) == "" {
+			deleted[t.header-1] = true
+		}
+	}
+
+	// Keys that disappeared are removed. Those that never made a
+	// difference, such as a default written out by hand, stay.
+	for _, k := range od.keys {
+		if value, ok := inline[k.path]; ok {
+			if !sameValue(k.value, value) {
+				replace[k.first] = k.prefix + value + k.suffix
+				deleteLines(k.first+1, k.last)
+			}
+			continue
+		}
+		if nd.key(k.path) == nil && saved.known(k.path) && saved.implied.key(k.path) != nil {
+			deleteLines(k.first, k.last)
+		}
+	}
+
+	// Changed values are replaced in place; new keys are added next to
+	// their siblings when the enclosing table already exists.
+	for _, nk := range nd.keys {
+		if inlined(nk.path) {
+			continue
+		}
+		if oldKey := od.key(nk.path); oldKey != nil {
+			if !sameValue(oldKey.value, nk.value) {
+				replace[oldKey.first] = oldKey.prefix + nk.value + oldKey.suffix
+				deleteLines(oldKey.first+1, oldKey.last)
+			}
+			continue
+		}
+		if saved.implies(nk) {
+			continue
+		}
+
+		if sibling := od.siblingFor(nk.table); sibling != nil {
+			indent := sibling.prefix[:len(sibling.prefix)-len(strings.TrimLeft(sibling.prefix, " \t"))]
+			rel := strings.TrimPrefix(nk.path, sibling.table+".")
+			line := indent + rel + " = " + nk.value
+			insertAfter[sibling.last] = append(insertAfter[sibling.last], line)
+			continue
+		}
+		if t := od.table(nk.table); t != nil {
+			insertAfter[t.header] = append(insertAfter[t.header], nd.lines[nk.first:nk.last+1]...)
+		}
+	}
+
+	// New tables are inserted after the table that precedes them in the
+	// desired document, leaving out keys the file already amounts to.
+	anchor := -1
+	if root := od.table(""); root != nil {
+		anchor = root.end
+	}
+	for _, nt := range nd.tables {
+		if nt.header < 0 {
+			continue
+		}
+		if ot := od.table(nt.path); ot != nil {
+			anchor = ot.end
+			continue
+		}
+		if inlined(nt.path) {
+			continue
+		}
+		if nt.path != "" && od.lastKeyUnder(nt.path) != nil {
+			continue
+		}
+
+		var keys []string
+		for _, k := range nt.keys {
+			if !saved.implies(k) {
+				keys = append(keys, nd.lines[k.first:k.last+1]...)
+			}
+		}
+		if len(keys) == 0 && (len(nt.keys) > 0 || saved.implied.table(nt.path) != nil) {
+			continue
+		}
+
+		block := append([]string{nd.lines[nt.header]}, keys...)
+		if anchor < 0 {
+			block = append(block, "")
+		} else {
+			block = append([]string{""}, block...)
+		}
+		insertAfter[anchor] = append(insertAfter[anchor], block...)
+	}
+
+	var out []string
+	out = append(out, insertAfter[-1]...)
+	for i, line := range od.lines {
+		if text, ok := replace[i]; ok {
+			out = append(out, strings.Split(text, "\n")...)
+		} else if !deleted[i] {
+			out = append(out, line)
+		}
+		out = append(out, insertAfter[i]...)
+	}
+
+	newline := "\n"
+	if od.crlf {
+		newline = "\r\n"
+	}
+	result := []byte(strings.Join(out, newline))
+
+	got, err := readSaved(result, base)
+	if err != nil {
+		return nil, fmt.Errorf("patched settings do not parse: %w", err)
+	}
+	want, err := readSaved(desired, base)
+	if err != nil {
+		return nil, err
+	}
+	if !sameDocument(got.implied, want.implied) {
+		return nil, errors.New("patched settings differ from the encoded settings")
+	}
+
+	return result, nil
+}
+
+// sameDocument reports whether a and b hold the same keys and values.
+func sameDocument(a, b *document) bool {
+	if len(a.keys) != len(b.keys) {
+		return false
+	}
+	for _, k := range a.keys {
+		other := b.key(k.path)
+		if other == nil || !sameValue(k.value, other.value) {
+			return false
+		}
+	}
+	return true
+}
+
+// encodeRoundTrip encodes settings and, when existing holds a parsable
+// document, patches that document instead of replacing it.
+func encodeRoundTrip(existing []byte, settings *Settings) ([]byte, error) {
+	desired, err := encodeDelta(settings)
+	if err != nil {
+		return nil, err
+	}
+
+	if existing == nil {
+		return desired, nil
+	}
+
+	patched, err := patchDocument(existing, desired, settings.base)
+	if err != nil {
+		return desired, nil
+	}
+	return patched, nil
+}
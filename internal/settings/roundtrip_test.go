@@ -0,0 +1,334 @@
+package settings
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestPatchDocument(t *testing.T) {
+	tests := []struct {
+		name    string
+		old     string
+		desired string
+		want    string
+	}{
+		{
+			name: "comments and key order",
+			old: `# my settings
+schema_version = 2
+
+[startup]
+window_locked = true   # keep it still
+start_with_windows = false
+
+# where the main window sits
+[window_position]
+y = 20
+x = 10
+`,
+			desired: `schema_version = 2
+
+[startup]
+start_with_windows = false
+window_locked = false
+
+[window_position]
+x = 15
+y = 20
+`,
+			want: `# my settings
+schema_version = 2
+
+[startup]
+window_locked = false   # keep it still
+start_with_windows = false
+
+# where the main window sits
+[window_position]
+y = 20
+x = 15
+`,
+		},
+		{
+			name: "unknown keys",
+			old: `schema_version = 2
+colour = "red" # not a setting
+
+[thumbnail_sise]
+width = 50
+
+[window_position]
+x = 10
+y = 20
+wdith = 5
+`,
+			desired: `schema_version = 2
+
+[window_position]
+x = 30
+y = 20
+`,
+			want: `schema_version = 2
+colour = "red" # not a setting
+
+[thumbnail_sise]
+width = 50
+
+[window_position]
+x = 30
+y = 20
+wdith = 5
+`,
+		},
+		{
+			name: "defaults left out",
+			old: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+`,
+			desired: `schema_version = 2
+
+[startup]
+  start_with_windows = false
+  window_locked = false
+
+[[drawers]]
+  id = "d1"
+  name = "Docs"
+  path = "/docs"
+  [drawers.size]
+    width = 640
+    height = 0
+
+[window_position]
+  x = 0
+  y = 0
+
+[thumbnail_size]
+  width = 96
+  height = 96
+
+[theme]
+  h = 192
+  s = 40
+  l = 36
+  a = 80
+
+[extension_icon_map]
+`,
+			want: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+  [drawers.size]
+    width = 640
+`,
+		},
+		{
+			name: "defaults written by hand",
+			old: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+confined = false
+`,
+			desired: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Renamed"
+path = "/docs"
+`,
+			want: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Renamed"
+path = "/docs"
+confined = false
+`,
+		},
+		{
+			name: "array of tables removed",
+			old: `schema_version = 2
+
+# first
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+# second
+[[drawers]]
+id = "d2"
+name = "Music"
+path = "/music"
+confined = true
+`,
+			desired: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+`,
+			want: `schema_version = 2
+
+# first
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+# second
+`,
+		},
+		{
+			name: "last array entry and its subtable removed",
+			old: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+[[drawers]]
+id = "d2"
+name = "Music"
+path = "/music"
+
+[drawers.size]
+width = 300
+height = 200
+
+[theme]
+h = 10
+s = 20
+l = 30
+a = 40
+`,
+			desired: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+[theme]
+h = 10
+s = 20
+l = 30
+a = 40
+`,
+			want: `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+[theme]
+h = 10
+s = 20
+l = 30
+a = 40
+`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := patchDocument([]byte(tt.old), []byte(tt.desired), nil)
+			if err != nil {
+				t.Fatalf("patchDocument: %v", err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("patchDocument =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPatchDocumentKeepsCRLF(t *testing.T) {
+	old := "schema_version = 2\r\n\r\n[window_position]\r\nx = 1\r\ny = 2\r\n"
+	desired := "schema_version = 2\n\n[window_position]\nx = 3\ny = 2\n"
+
+	got, err := patchDocument([]byte(old), []byte(desired), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "schema_version = 2\r\n\r\n[window_position]\r\nx = 3\r\ny = 2\r\n"; string(got) != want {
+		t.Errorf("patchDocument = %q, want %q", got, want)
+	}
+}
+
+// Saving unrelated changes neither drops a misspelled table nor writes out
+// every default.
+func TestUpdateKeepsHandWrittenFile(t *testing.T) {
+	original := `schema_version = 2
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+[thumbnail_sise]
+width = 50
+`
+	path := writeSettings(t, original)
+	s, report, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.UnknownKeys) != 1 || report.UnknownKeys[0].Key != "thumbnail_sise" {
+		t.Errorf("UnknownKeys = %v, want thumbnail_sise", report.UnknownKeys)
+	}
+	if got := readFile(t, path); got != original {
+		t.Errorf("Read rewrote the file:\n%s", got)
+	}
+
+	s.Drawers[0].Size = Size{Width: 640, Height: 480}
+	if err := Update(path, s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := original[:strings.Index(original, "\n[thumbnail_sise]")] +
+		"\n  [drawers.size]\n    width = 640\n    height = 480\n\n[thumbnail_sise]\nwidth = 50\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("file after Update =\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Entries laid over the baseline keep their id even where the baseline
+// already has it, or they would no longer override the baseline entry.
+func TestUpdateOverBaselineWritesIDs(t *testing.T) {
+	path := writeSettings(t, "# mine\nschema_version = 2\n")
+	base := path + ".baseline"
+	if err := os.WriteFile(base, []byte("schema_version = 2\n\n[[drawers]]\nid = \"b1\"\nname = \"Shared\"\npath = \"/shared\"\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvBaselinePath, base)
+
+	s, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Drawers[0].Name = "Renamed"
+	if err := Update(path, s); err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# mine\nschema_version = 2\n\n[[drawers]]\n  id = \"b1\"\n  name = \"Renamed\"\n"
+	if got := readFile(t, path); got != want {
+		t.Errorf("file after Update =\n%s\nwant\n%s", got, want)
+	}
+}
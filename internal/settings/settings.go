@@ -1,17 +1,57 @@
 package settings
 
 import (
+	"bytes"
+	"errors"
 	"fmt"
+	"io"
 	"os"
-
-	"github.com/BurntSushi/toml"
+	"path/filepath"
 )
 
 // Drawer represents a drawer configuration.
 type Drawer struct {
+	ID   string `toml:"id"`
 	Name string `toml:"name"`
-	Path string `toml:"path"`
-	Size Size   `toml:"size"`
+	// Path may contain environment variables, known-folder tokens such as
+	// {Desktop}, a leading ~, or be relative to the settings file. It is
+	// kept as written; use Settings.ResolvePath to get the real folder.
+	Path  string `toml:"path"`
+	Group string `toml:"group,omitempty"`
+	// Profile is the ID of the profile the drawer belongs to. Drawers
+	// without one are shared by every profile.
+	Profile string `toml:"profile,omitempty"`
+	// Confined keeps the drawer window inside Path: it cannot go up past
+	// it, and links leading elsewhere are not followed.
+	Confined bool       `toml:"confined,omitempty"`
+	Size     Size       `toml:"size"`
+	View     *ViewState `toml:"view,omitempty"`
+}
+
+// Group is a named, collapsible section of drawers in the main window.
+// Drawers refer to it through Drawer.Group; drawers without a group, or
+// with an unknown one, are shown ungrouped.
+type Group struct {
+	ID        string `toml:"id"`
+	Name      string `toml:"name"`
+	Collapsed bool   `toml:"collapsed"`
+}
+
+// Sort orders stored in ViewState.SortOrder.
+const (
+	SortAscending  = "asc"
+	SortDescending = "desc"
+)
+
+// ViewState remembers how a drawer window was left so it can be restored
+// the next time the drawer opens. Drawers that were never opened have none.
+type ViewState struct {
+	SortColumn   int    `toml:"sort_column,omitzero"`
+	SortOrder    string `toml:"sort_order,omitempty"`
+	ColumnWidths []int  `toml:"column_widths,omitempty"`
+	Subpath      string `toml:"subpath,omitempty"`
+	Position     *Point `toml:"position,omitempty"`
+	Filter       string `toml:"filter,omitempty"`
 }
 
 // Size represents the size of a drawer window.
@@ -36,13 +76,26 @@ type Theme struct {
 
 // Settings represents the complete configuration.
 type Settings struct {
+	SchemaVersion    int               `toml:"schema_version"`
+	ActiveProfile    string            `toml:"active_profile,omitempty"`
 	Startup          Startup           `toml:"startup"`
 	Drawers          []Drawer          `toml:"drawers"`
+	Groups           []Group           `toml:"groups,omitempty"`
+	Profiles         []Profile         `toml:"profiles,omitempty"`
 	WindowPosition   Point             `toml:"window_position"`
 	ThumbnailSize    Size              `toml:"thumbnail_size"`
 	Theme            Theme             `toml:"theme"`
 	ExtensionIconMap map[string]string `toml:"extension_icon_map"`
+	Control          Control           `toml:"control,omitempty"`
 	Deprecated       map[string]string `toml:"deprecated,omitempty"`
+
+	// base is the baseline document the settings were merged over, or nil.
+	// Update writes only what differs from it.
+	base map[string]any
+
+	// dir is the directory of the settings file. Relative drawer and icon
+	// paths are resolved against it.
+	dir string
 }
 
 // Point represents a 2D point.
@@ -56,123 +109,324 @@ func DefaultTheme() Theme {
 	return Theme{Hue: 192, Saturation: 40, Lightness: 36, Alpha: 80}
 }
 
-// Read reads and parses the goDrawer-settings.toml file.
-func Read(path string) (*Settings, error) {
+// Read reads and parses the goDrawer-settings.toml file. Files written by
+// older releases are upgraded to CurrentSchemaVersion; the original is kept
+// next to it and every applied step is listed in the returned Report. When
+// the file cannot be parsed, the newest readable backup is used instead.
+func Read(path string) (*Settings, *Report, error) {
+	return read(path, true)
+}
+
+// Reload reads path like Read but never falls back to a backup. It is meant
+// for files edited while goDrawer runs, which may be saved half-finished.
+func Reload(path string) (*Settings, *Report, error) {
+	return read(path, false)
+}
+
+// Load reads path without ever changing it: a missing file is an error,
+// migrations and new ids are only applied in memory, and a file that does
+// not parse is not replaced by a backup. The Report lists what Read would
+// have written.
+func Load(path string) (*Settings, *Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read settings file: %w", err)
+	}
+
+	report := &Report{}
+	settings, err := parse(data, readBaseline(report), report)
+	if err != nil {
+		return nil, nil, err
+	}
+	settings.dir = filepath.Dir(path)
+	return settings, report, nil
+}
+
+func read(path string, fallback bool) (*Settings, *Report, error) {
+	report := &Report{}
+
 	data, err := os.ReadFile(path)
 	if err != nil {
 		if os.IsNotExist(err) {
-			Init(path)
+			if report.Created, err = Init(path); err != nil {
+				return nil, nil, err
+			}
 			data, err = os.ReadFile(path)
 		}
 		if err != nil {
-			return nil, fmt.Errorf("failed to read settings file: %w", err)
+			return nil, nil, fmt.Errorf("failed to read settings file: %w", err)
 		}
 	}
 
-	var settings Settings
-	if _, err := toml.Decode(string(data), &settings); err != nil {
-		return nil, fmt.Errorf("failed to parse TOML file: %w", err)
+	base := readBaseline(report)
+
+	settings, err := parse(data, base, report)
+	if err != nil {
+		// Only a damaged file is replaced by a backup. One that is fine but
+		// cannot be used, such as a file from a newer release, is left as
+		// it is.
+		var decodeErr *decodeError
+		if !fallback || !errors.As(err, &decodeErr) {
+			return nil, nil, err
+		}
+		recovered, recoverErr := recoverFromBackup(path, base, report)
+		if recoverErr != nil {
+			return nil, nil, err
+		}
+		report.ParseError = err
+		settings = recovered
 	}
+	settings.dir = filepath.Dir(path)
 
-	settings.applyDefaults()
-	return &settings, nil
+	if report.RecoveredFrom == "" && (len(report.Migrations) > 0 || report.AssignedIDs > 0) {
+		if len(report.Migrations) > 0 {
+			backup, err := backupBeforeMigration(path, data, report.Migrations[0].From)
+			if err != nil {
+				return nil, nil, err
+			}
+			report.BackupPath = backup
+		}
+
+		if err := Update(path, settings); err != nil {
+			return nil, nil, fmt.Errorf("failed to write migrated settings: %w", err)
+		}
+	}
+
+	return settings, report, nil
 }
 
-// Update updates the settings file with the provided settings.
-func Update(path string, settings *Settings) error {
-	file, err := os.Create(path)
+// readBaseline loads the baseline file, if there is one. A baseline that
+// cannot be read is reported and ignored.
+func readBaseline(report *Report) *baseline {
+	path := LocateBaseline()
+	if path == "" {
+		return nil
+	}
+
+	base, err := loadBaseline(path)
+	if err != nil {
+		report.BaselineError = err
+		return nil
+	}
+	report.Baseline = path
+	return base
+}
+
+// parse migrates raw file contents and decodes them over base, which may be
+// nil.
+func parse(data []byte, base *baseline, report *Report) (*Settings, error) {
+	migrated, err := migrateData(data, report)
 	if err != nil {
-		return fmt.Errorf("failed to open settings file for writing: %w", err)
+		return nil, err
 	}
-	defer file.Close()
 
-	encoder := toml.NewEncoder(file)
-	if err := encoder.Encode(settings); err != nil {
-		return fmt.Errorf("failed to encode settings: %w", err)
+	settings := Settings{base: base.document()}
+	md, err := decodeLayered(migrated, base.document(), &settings)
+	if err != nil {
+		return nil, &decodeError{err}
 	}
+	report.UnknownKeys = unknownKeys(data, migrated, base, md.Undecoded())
+
+	settings.applyDefaults()
+	report.AssignedIDs = settings.ensureDrawerIDs() + settings.ensureGroupIDs() + settings.ensureProfileIDs()
+	return &settings, nil
+}
+
+// decodeError is returned by parse when the file is not valid TOML or does
+// not fit the schema.
+type decodeError struct {
+	err error
+}
+
+func (e *decodeError) Error() string {
+	return "failed to parse TOML file: " + e.err.Error()
+}
 
-	return nil
+func (e *decodeError) Unwrap() error {
+	return e.err
 }
 
-// Init creates the settings file using default values if it does not exist.
-func Init(path string) {
-	if _, err := os.Stat(path); os.IsNotExist(err) {
-		defaultSettings := Settings{
-			Startup: Startup{
-				StartWithWindows: false,
-				WindowLocked:     false,
-			},
-			Drawers: []Drawer{
-				{Name: "Drawer 1", Path: "C:\\", Size: Size{Width: 800, Height: 600}},
-			},
-			WindowPosition:   Point{X: 100, Y: 100},
-			ThumbnailSize:    Size{Width: 96, Height: 96},
-			Theme:            DefaultTheme(),
-			ExtensionIconMap: map[string]string{},
-			Deprecated:       map[string]string{},
+// recoverFromBackup loads the newest backup of path that still parses. The
+// unreadable file is moved aside to <path>.corrupt and the recovered
+// settings are written back in its place.
+func recoverFromBackup(path string, base *baseline, report *Report) (*Settings, error) {
+	for _, backup := range backupPaths(path) {
+		data, err := os.ReadFile(backup)
+		if err != nil {
+			continue
 		}
 
-		defaultSettings.applyDefaults()
+		candidate := &Report{}
+		settings, err := parse(data, base, candidate)
+		if err != nil {
+			continue
+		}
 
-		file, createErr := os.Create(path)
-		if createErr != nil {
-			fmt.Println("Error creating settings file:", createErr)
-			return
+		if err := os.Rename(path, path+".corrupt"); err != nil {
+			return nil, fmt.Errorf("failed to move corrupt settings file aside: %w", err)
 		}
-		defer file.Close()
+		if err := Update(path, settings); err != nil {
+			return nil, fmt.Errorf("failed to restore settings from backup: %w", err)
+		}
+
+		report.Migrations = candidate.Migrations
+		report.AssignedIDs = candidate.AssignedIDs
+		report.UnknownKeys = candidate.UnknownKeys
+		report.RecoveredFrom = backup
+		return settings, nil
+	}
+
+	return nil, fmt.Errorf("no readable backup of %s", path)
+}
+
+// Update atomically replaces the settings file with the provided settings.
+// Values are patched into the existing document so comments, blank lines
+// and key order written by hand are preserved. The previous file is kept as
+// a rotating .bak generation.
+func Update(path string, settings *Settings) error {
+	existing, err := os.ReadFile(path)
+	if err != nil {
+		existing = nil
+	}
+
+	data, err := encodeRoundTrip(existing, settings)
+	if err != nil {
+		return err
+	}
+	if existing != nil && bytes.Equal(data, existing) {
+		return nil
+	}
 
-		encoder := toml.NewEncoder(file)
-		if encodeErr := encoder.Encode(defaultSettings); encodeErr != nil {
-			fmt.Println("Error encoding default settings:", encodeErr)
-			return
+	return writeFileAtomic(path, data)
+}
+
+// Init creates the settings file using default values if it does not exist
+// and reports whether it did. With a baseline in place the new file starts
+// empty and everything comes from the baseline.
+func Init(path string) (bool, error) {
+	if _, err := os.Stat(path); err == nil {
+		return false, nil
+	} else if !os.IsNotExist(err) {
+		return false, fmt.Errorf("failed to check settings file: %w", err)
+	}
+
+	if LocateBaseline() != "" {
+		data := fmt.Sprintf("schema_version = %d\n", CurrentSchemaVersion)
+		if err := writeFileAtomic(path, []byte(data)); err != nil {
+			return false, fmt.Errorf("failed to create settings file: %w", err)
 		}
+		return true, nil
+	}
 
-		fmt.Println("Settings file created successfully.")
-	} else if err != nil {
-		fmt.Println("Error checking settings file:", err)
-	} else {
-		fmt.Println("Settings file already exists.")
+	defaultSettings := Settings{
+		SchemaVersion: CurrentSchemaVersion,
+		Startup: Startup{
+			StartWithWindows: false,
+			WindowLocked:     false,
+		},
+		Drawers: []Drawer{
+			{ID: NewDrawerID(), Name: "Drawer 1", Path: "C:\\", Size: Size{Width: 800, Height: 600}},
+		},
+		WindowPosition:   Point{X: 100, Y: 100},
+		ThumbnailSize:    Size{Width: 96, Height: 96},
+		Theme:            DefaultTheme(),
+		ExtensionIconMap: map[string]string{},
+		Deprecated:       map[string]string{},
 	}
+
+	defaultSettings.applyDefaults()
+
+	if err := Update(path, &defaultSettings); err != nil {
+		return false, fmt.Errorf("failed to create settings file: %w", err)
+	}
+	return true, nil
 }
 
 // Print categorizes and prints the settings information.
 func Print(settings *Settings) {
-	fmt.Println("=== Drawers Settings ===")
-	fmt.Println()
+	Fprint(os.Stdout, settings)
+}
 
-	fmt.Println(":: Startup ::")
-	fmt.Printf("  Start with Windows: %t\n", settings.Startup.StartWithWindows)
-	fmt.Printf("  Window Locked: %t\n", settings.Startup.WindowLocked)
-	fmt.Println()
+// Fprint is Print writing to w.
+func Fprint(w io.Writer, settings *Settings) {
+	fmt.Fprintln(w, "=== Drawers Settings ===")
+	fmt.Fprintf(w, "  Schema version: %d\n", settings.SchemaVersion)
+	fmt.Fprintln(w)
 
-	fmt.Println(":: Drawers ::")
+	fmt.Fprintln(w, ":: Startup ::")
+	fmt.Fprintf(w, "  Start with Windows: %t\n", settings.Startup.StartWithWindows)
+	fmt.Fprintf(w, "  Window Locked: %t\n", settings.Startup.WindowLocked)
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, ":: Drawers ::")
 	for i, drawer := range settings.Drawers {
-		fmt.Printf("  %d. %s\n", i+1, drawer.Name)
-		fmt.Printf("     Path: %s\n", drawer.Path)
-		fmt.Printf("     Size: %dx%d\n", drawer.Size.Width, drawer.Size.Height)
-		fmt.Println()
+		fmt.Fprintf(w, "  %d. %s\n", i+1, drawer.Name)
+		fmt.Fprintf(w, "     ID: %s\n", drawer.ID)
+		fmt.Fprintf(w, "     Path: %s\n", drawer.Path)
+		if drawer.Group != "" {
+			fmt.Fprintf(w, "     Group: %s\n", drawer.Group)
+		}
+		if drawer.Profile != "" {
+			fmt.Fprintf(w, "     Profile: %s\n", drawer.Profile)
+		}
+		fmt.Fprintf(w, "     Confined: %t\n", drawer.Confined)
+		fmt.Fprintf(w, "     Size: %dx%d\n", drawer.Size.Width, drawer.Size.Height)
+		fmt.Fprintln(w)
+	}
+
+	if len(settings.Groups) > 0 {
+		fmt.Fprintln(w, ":: Groups ::")
+		for _, group := range settings.Groups {
+			fmt.Fprintf(w, "  %s (%s, collapsed: %t)\n", group.Name, group.ID, group.Collapsed)
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(settings.Profiles) > 0 {
+		fmt.Fprintln(w, ":: Profiles ::")
+		active := BaseProfileName
+		if p := settings.Profile(); p != nil {
+			active = p.Name
+		}
+		fmt.Fprintf(w, "  Active: %s\n", active)
+		for _, profile := range settings.Profiles {
+			fmt.Fprintf(w, "  %s (%s)\n", profile.Name, profile.ID)
+			if profile.Theme != nil {
+				fmt.Fprintf(w, "     Theme: H %d, S %d, L %d, A %d\n", profile.Theme.Hue, profile.Theme.Saturation, profile.Theme.Lightness, profile.Theme.Alpha)
+			}
+			for ext, icon := range profile.ExtensionIconMap {
+				fmt.Fprintf(w, "     %s -> %s\n", ext, icon)
+			}
+		}
+		fmt.Fprintln(w)
 	}
 
-	fmt.Println(":: Window Position ::")
-	fmt.Printf("  Position: (%d, %d)\n", settings.WindowPosition.X, settings.WindowPosition.Y)
-	fmt.Println()
+	fmt.Fprintln(w, ":: Window Position ::")
+	fmt.Fprintf(w, "  Position: (%d, %d)\n", settings.WindowPosition.X, settings.WindowPosition.Y)
+	fmt.Fprintln(w)
 
-	fmt.Println(":: Thumbnail ::")
-	fmt.Printf("  Size: %dx%d\n", settings.ThumbnailSize.Width, settings.ThumbnailSize.Height)
-	fmt.Println()
+	fmt.Fprintln(w, ":: Thumbnail ::")
+	fmt.Fprintf(w, "  Size: %dx%d\n", settings.ThumbnailSize.Width, settings.ThumbnailSize.Height)
+	fmt.Fprintln(w)
 
-	fmt.Println(":: Theme (HSLA) ::")
-	fmt.Printf("  H: %d\n", settings.Theme.Hue)
-	fmt.Printf("  S: %d\n", settings.Theme.Saturation)
-	fmt.Printf("  L: %d\n", settings.Theme.Lightness)
-	fmt.Printf("  A: %d\n", settings.Theme.Alpha)
-	fmt.Println()
+	fmt.Fprintln(w, ":: Theme (HSLA) ::")
+	fmt.Fprintf(w, "  H: %d\n", settings.Theme.Hue)
+	fmt.Fprintf(w, "  S: %d\n", settings.Theme.Saturation)
+	fmt.Fprintf(w, "  L: %d\n", settings.Theme.Lightness)
+	fmt.Fprintf(w, "  A: %d\n", settings.Theme.Alpha)
+	fmt.Fprintln(w)
 
-	fmt.Println(":: Extension Icon Map ::")
+	fmt.Fprintln(w, ":: Extension Icon Map ::")
 	for ext, icon := range settings.ExtensionIconMap {
-		fmt.Printf("  %s -> %s\n", ext, icon)
+		fmt.Fprintf(w, "  %s -> %s\n", ext, icon)
+	}
+	fmt.Fprintln(w)
+
+	if settings.Control.Enabled {
+		fmt.Fprintln(w, ":: Control API ::")
+		fmt.Fprintf(w, "  Listening on: 127.0.0.1:%d\n", settings.Control.PortOrDefault())
+		fmt.Fprintln(w)
 	}
-	fmt.Println()
 }
 
 func (s *Settings) applyDefaults() {
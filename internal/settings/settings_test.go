@@ -0,0 +1,162 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeSettings writes a settings file with contents into a new temporary
+// directory and returns its path. No baseline is merged under it.
+func writeSettings(t *testing.T, contents string) string {
+	t.Helper()
+	t.Setenv(EnvBaselinePath, "")
+
+	path := filepath.Join(t.TempDir(), FileName)
+	if err := os.WriteFile(path, []byte(contents), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestReadRecoversFromBackup(t *testing.T) {
+	path := writeSettings(t, "schema_version = 2\n\n[[drawers]]\nid = \"d1\"\nname = \"Good\"\npath = \"/good\"\n")
+	if err := os.Rename(path, backupPath(path, 1)); err != nil {
+		t.Fatal(err)
+	}
+	broken := "schema_version = 2\n[[drawers]\n"
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	s, report, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read: %v", err)
+	}
+	if report.RecoveredFrom != backupPath(path, 1) || report.ParseError == nil {
+		t.Errorf("RecoveredFrom, ParseError = %q, %v, want the backup and the parse error", report.RecoveredFrom, report.ParseError)
+	}
+	if len(s.Drawers) != 1 || s.Drawers[0].Name != "Good" {
+		t.Errorf("Drawers = %+v, want the drawer from the backup", s.Drawers)
+	}
+	if got := readFile(t, path+".corrupt"); got != broken {
+		t.Errorf("corrupt file = %q, want %q", got, broken)
+	}
+}
+
+func TestReadRecoversFromMistypedFile(t *testing.T) {
+	path := writeSettings(t, "schema_version = 2\n")
+	if err := os.Rename(path, backupPath(path, 1)); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("schema_version = 2\ndrawers = 5\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, report, err := Read(path); err != nil || report.RecoveredFrom == "" {
+		t.Errorf("Read = %v, recovered from %q, want recovery from the backup", err, report.RecoveredFrom)
+	}
+}
+
+func TestReadKeepsNewerSchema(t *testing.T) {
+	newer := "schema_version = 99\n\n[[drawers]]\nid = \"d1\"\nname = \"New\"\npath = \"/new\"\n"
+	path := writeSettings(t, newer)
+	if err := os.WriteFile(backupPath(path, 1), []byte("schema_version = 2\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	_, _, err := Read(path)
+	if err == nil || !strings.Contains(err.Error(), "newer than supported") {
+		t.Fatalf("Read = %v, want the newer schema error", err)
+	}
+	if got := readFile(t, path); got != newer {
+		t.Errorf("settings file changed to %q", got)
+	}
+	if _, err := os.Stat(path + ".corrupt"); !os.IsNotExist(err) {
+		t.Errorf("settings file was moved aside: %v", err)
+	}
+}
+
+func TestUpdateKeepsFileMode(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("file modes are not kept on Windows")
+	}
+
+	path := writeSettings(t, "schema_version = 2\n")
+	if err := os.Chmod(path, 0o640); err != nil {
+		t.Fatal(err)
+	}
+	s, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	s.WindowPosition = Point{X: 5, Y: 5}
+	if err := Update(path, s); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0o640 {
+		t.Errorf("mode after Update = %v (%v), want 0640", info.Mode().Perm(), err)
+	}
+
+	created := filepath.Join(filepath.Dir(path), "new.toml")
+	if err := Update(created, s); err != nil {
+		t.Fatal(err)
+	}
+	if info, err := os.Stat(created); err != nil || info.Mode().Perm() != 0o644 {
+		t.Errorf("mode of new file = %v (%v), want 0644", info.Mode().Perm(), err)
+	}
+}
+
+func TestLoadLeavesFileAlone(t *testing.T) {
+	old := "[[drawers]]\nname = \"Docs\"\npath = \"/docs\"\n"
+	path := writeSettings(t, old)
+
+	s, report, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Migrations) == 0 || report.AssignedIDs != 0 {
+		t.Errorf("report = %+v, want migrations and no new ids after them", report)
+	}
+	if len(s.Drawers) != 1 || s.Drawers[0].ID == "" {
+		t.Errorf("drawers = %+v, want one with an id", s.Drawers)
+	}
+	if got := readFile(t, path); got != old {
+		t.Errorf("settings file changed to %q", got)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("directory has %d entries, want only the settings file", len(entries))
+	}
+
+	broken := "[[drawers]\n"
+	if err := os.WriteFile(path, []byte(broken), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := Load(path); err == nil {
+		t.Error("Load of a broken file succeeded")
+	}
+	if got := readFile(t, path); got != broken {
+		t.Errorf("broken settings file changed to %q", got)
+	}
+
+	missing := filepath.Join(t.TempDir(), FileName)
+	if _, _, err := Load(missing); !os.IsNotExist(errors.Unwrap(err)) {
+		t.Errorf("Load of a missing file = %v, want not exist", err)
+	}
+	if _, err := os.Stat(missing); !os.IsNotExist(err) {
+		t.Errorf("Load created the missing file: %v", err)
+	}
+}
@@ -0,0 +1,485 @@
+package settings
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultSaveDelay is how long a Store waits after the last change before
+// writing the file, so bursts of changes end up in a single write.
+const DefaultSaveDelay = 500 * time.Millisecond
+
+// ErrConflict is returned when the settings file was changed on disk since
+// the Store last read or wrote it. The Store does not overwrite such edits
+// on its own; see Store.Overwrite and Store.Replace.
+var ErrConflict = errors.New("settings file was changed on disk")
+
+// Event describes a change made through a Store.
+type Event struct {
+	Old     *Settings
+	New     *Settings
+	Changes Change
+	// Cause is a short description of what made the change, e.g. "rename
+	// drawer" or "reload".
+	Cause string
+}
+
+// Store owns the settings of a running application. Reads hand out
+// snapshots and changes are applied as transactions, so it can be shared
+// between the UI and background goroutines. Changes are written to disk
+// after a short delay, coalescing bursts into one write.
+type Store struct {
+	path  string
+	delay time.Duration
+
+	mu      sync.RWMutex
+	current *Settings
+	version int
+
+	// saved is the state of the file as last read or written, at version
+	// savedVersion with the given fingerprint.
+	saved        *Settings
+	savedVersion int
+	fingerprint  string
+
+	// history is guarded by mu and written together with the settings.
+	history     *History
+	historyPath string
+
+	subMu       sync.Mutex
+	subscribers map[int]func(Event)
+	nextSub     int
+
+	// writeMu serializes disk writes and guards the fields below it; timer
+	// is the pending debounced save.
+	writeMu sync.Mutex
+	timer   *time.Timer
+	onError func(error)
+	closed  bool
+
+	watchMu sync.Mutex
+	watcher *Watcher
+}
+
+// NewStore returns a Store for settings that were just read from path.
+// delay is the debounce interval for saves; zero means DefaultSaveDelay.
+// The undo history is loaded from HistoryPath(path); an unreadable history
+// file is replaced by an empty history on the next save.
+func NewStore(path string, settings *Settings, delay time.Duration) *Store {
+	if delay <= 0 {
+		delay = DefaultSaveDelay
+	}
+
+	st := &Store{
+		path:        path,
+		delay:       delay,
+		current:     settings,
+		saved:       settings.Clone(),
+		historyPath: HistoryPath(path),
+		subscribers: map[int]func(Event){},
+	}
+	st.history, _ = LoadHistory(st.historyPath, DefaultHistoryLimit)
+	st.fingerprint, _ = Fingerprint(path)
+	return st
+}
+
+// Path returns the settings file the Store writes to.
+func (st *Store) Path() string {
+	return st.path
+}
+
+// Snapshot returns the current settings. The result is shared and must be
+// treated as read-only; change settings through Update.
+func (st *Store) Snapshot() *Settings {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.current
+}
+
+// Saved returns the settings as they were last read from or written to
+// disk. Like Snapshot, the result is read-only.
+func (st *Store) Saved() *Settings {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.saved
+}
+
+// Update applies fn to a copy of the current settings. When fn returns an
+// error nothing changes and the error is returned. Otherwise the copy
+// becomes current, the previous state is added to the undo history,
+// subscribers are notified and a save is scheduled. Subscribers run on the
+// calling goroutine after the lock is released.
+func (st *Store) Update(cause string, fn func(*Settings) error) error {
+	return st.update(cause, fn, true)
+}
+
+// Adjust is Update without an undo step, for changes nobody wants to undo
+// such as window positions and view state. Undo restores whole states, so
+// undoing an earlier change also reverts adjustments made since.
+func (st *Store) Adjust(cause string, fn func(*Settings) error) error {
+	return st.update(cause, fn, false)
+}
+
+func (st *Store) update(cause string, fn func(*Settings) error, undoable bool) error {
+	st.mu.Lock()
+	old := st.current
+	next := old.Clone()
+	if err := fn(next); err != nil {
+		st.mu.Unlock()
+		return err
+	}
+
+	changes := Diff(old, next)
+	if changes == 0 {
+		st.mu.Unlock()
+		return nil
+	}
+	if undoable {
+		st.history.record(cause, old)
+	}
+	st.current = next
+	st.version++
+	st.mu.Unlock()
+
+	st.scheduleSave()
+	st.notify(Event{Old: old, New: next, Changes: changes, Cause: cause})
+	return nil
+}
+
+// UndoCause returns the cause of the change Undo would revert.
+func (st *Store) UndoCause() (string, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return lastCause(st.history.Undo)
+}
+
+// RedoCause returns the cause of the change Redo would make again.
+func (st *Store) RedoCause() (string, bool) {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return lastCause(st.history.Redo)
+}
+
+// History returns the states Restore accepts, newest first.
+func (st *Store) History() []Snapshot {
+	st.mu.RLock()
+	defer st.mu.RUnlock()
+	return st.history.Snapshots()
+}
+
+// Undo reverts the most recent undoable change. It returns
+// ErrNothingToUndo when there is none.
+func (st *Store) Undo() error {
+	return st.travel(func(current *Settings) (Snapshot, string, error) {
+		snapshot, ok := st.history.undo(current)
+		if !ok {
+			return Snapshot{}, "", ErrNothingToUndo
+		}
+		return snapshot, "undo " + snapshot.Cause, nil
+	})
+}
+
+// Redo makes the most recently undone change again. It returns
+// ErrNothingToRedo when there is none.
+func (st *Store) Redo() error {
+	return st.travel(func(current *Settings) (Snapshot, string, error) {
+		snapshot, ok := st.history.redo(current)
+		if !ok {
+			return Snapshot{}, "", ErrNothingToRedo
+		}
+		return snapshot, "redo " + snapshot.Cause, nil
+	})
+}
+
+// Restore brings back the state with the given snapshot ID, as listed by
+// History. The restore itself can be undone.
+func (st *Store) Restore(id int) error {
+	return st.travel(func(current *Settings) (Snapshot, string, error) {
+		snapshot, ok := st.history.find(id)
+		if !ok {
+			return Snapshot{}, "", fmt.Errorf("%w: #%d", ErrUnknownSnapshot, id)
+		}
+		cause := fmt.Sprintf("restore #%d", id)
+		st.history.record(cause, current)
+		return snapshot, cause, nil
+	})
+}
+
+// travel makes the state picked by pick current. pick runs with the lock
+// held and updates the history.
+func (st *Store) travel(pick func(*Settings) (Snapshot, string, error)) error {
+	st.mu.Lock()
+	old := st.current
+	snapshot, cause, err := pick(old)
+	if err != nil {
+		st.mu.Unlock()
+		return err
+	}
+
+	// Stored states may come from the history file, which knows nothing of
+	// the baseline or where the settings file lives.
+	next := snapshot.Settings.Clone()
+	next.base, next.dir = old.base, old.dir
+	st.current = next
+	st.version++
+	st.mu.Unlock()
+
+	st.scheduleSave()
+	if changes := Diff(old, next); changes != 0 {
+		st.notify(Event{Old: old, New: next, Changes: changes, Cause: cause})
+	}
+	return nil
+}
+
+// Replace makes settings, just read from disk, the current and saved
+// state without writing them back. The previous state stays in the undo
+// history, so an unwanted edit on disk can be undone.
+func (st *Store) Replace(cause string, settings *Settings) {
+	fingerprint, _ := Fingerprint(st.path)
+
+	st.mu.Lock()
+	old := st.current
+	if Diff(old, settings) != 0 {
+		st.history.record(cause, old)
+	}
+	st.current = settings
+	st.version++
+	st.saved = settings.Clone()
+	st.savedVersion = st.version
+	st.fingerprint = fingerprint
+	st.mu.Unlock()
+
+	st.acknowledge(fingerprint)
+	if changes := Diff(old, settings); changes != 0 {
+		st.scheduleSave()
+		st.notify(Event{Old: old, New: settings, Changes: changes, Cause: cause})
+	}
+}
+
+// Subscribe registers fn to be called after every change. The returned
+// function removes the subscription.
+func (st *Store) Subscribe(fn func(Event)) func() {
+	st.subMu.Lock()
+	id := st.nextSub
+	st.nextSub++
+	st.subscribers[id] = fn
+	st.subMu.Unlock()
+
+	return func() {
+		st.subMu.Lock()
+		delete(st.subscribers, id)
+		st.subMu.Unlock()
+	}
+}
+
+// notify calls the subscribers in subscription order.
+func (st *Store) notify(event Event) {
+	st.subMu.Lock()
+	ids := make([]int, 0, len(st.subscribers))
+	for id := range st.subscribers {
+		ids = append(ids, id)
+	}
+	slices.Sort(ids)
+	fns := make([]func(Event), len(ids))
+	for i, id := range ids {
+		fns[i] = st.subscribers[id]
+	}
+	st.subMu.Unlock()
+
+	for _, fn := range fns {
+		fn(event)
+	}
+}
+
+// HandleSaveErrors sets the function called when a debounced save fails,
+// including with ErrConflict. It runs on the timer goroutine.
+func (st *Store) HandleSaveErrors(fn func(error)) {
+	st.writeMu.Lock()
+	st.onError = fn
+	st.writeMu.Unlock()
+}
+
+func (st *Store) scheduleSave() {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	if st.closed {
+		return
+	}
+	if st.timer != nil {
+		st.timer.Stop()
+	}
+	st.timer = time.AfterFunc(st.delay, func() {
+		if err := st.Flush(); err != nil {
+			st.writeMu.Lock()
+			onError := st.onError
+			st.writeMu.Unlock()
+			if onError != nil {
+				onError(err)
+			}
+		}
+	})
+}
+
+// Flush writes pending changes now. It returns ErrConflict, and writes
+// nothing, when the file was changed on disk in the meantime.
+func (st *Store) Flush() error {
+	return st.save(false)
+}
+
+// Overwrite writes the current settings even if the file was changed on
+// disk, discarding those changes.
+func (st *Store) Overwrite() error {
+	return st.save(true)
+}
+
+func (st *Store) save(force bool) error {
+	st.writeMu.Lock()
+	defer st.writeMu.Unlock()
+
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+
+	st.mu.RLock()
+	current, version := st.current, st.version
+	dirty := version != st.savedVersion
+	known := st.fingerprint
+	history, historyChanges, historyErr := st.history.pending()
+	st.mu.RUnlock()
+
+	if historyErr != nil {
+		return historyErr
+	}
+	if history != nil {
+		if err := st.saveHistory(history, historyChanges); err != nil {
+			return err
+		}
+	}
+
+	if !dirty && !force {
+		return nil
+	}
+
+	if !force {
+		fingerprint, err := Fingerprint(st.path)
+		if err != nil {
+			return err
+		}
+		if fingerprint != known {
+			return ErrConflict
+		}
+	}
+
+	if err := Update(st.path, current); err != nil {
+		return err
+	}
+	fingerprint, err := Fingerprint(st.path)
+	if err != nil {
+		return err
+	}
+
+	st.mu.Lock()
+	st.saved = current.Clone()
+	st.savedVersion = version
+	st.fingerprint = fingerprint
+	st.mu.Unlock()
+
+	st.acknowledge(fingerprint)
+	return nil
+}
+
+func (st *Store) saveHistory(data []byte, changes int) error {
+	if err := replaceFile(st.historyPath, data, false); err != nil {
+		return fmt.Errorf("failed to write settings history: %w", err)
+	}
+
+	st.mu.Lock()
+	st.history.written = changes
+	st.mu.Unlock()
+	return nil
+}
+
+// Watch polls the settings file and calls onChange on the polling goroutine
+// when it was changed by something other than this Store.
+func (st *Store) Watch(interval time.Duration, onChange func()) {
+	st.watchMu.Lock()
+	defer st.watchMu.Unlock()
+
+	if st.watcher != nil {
+		return
+	}
+	st.watcher = Watch(st.path, interval, func(string) { onChange() })
+
+	st.mu.RLock()
+	st.watcher.Acknowledge(st.fingerprint)
+	st.mu.RUnlock()
+}
+
+func (st *Store) acknowledge(fingerprint string) {
+	st.watchMu.Lock()
+	defer st.watchMu.Unlock()
+
+	if st.watcher != nil {
+		st.watcher.Acknowledge(fingerprint)
+	}
+}
+
+// Close stops watching and writes pending changes. When the file was
+// changed on disk in the meantime, the pending settings are written to
+// UnsavedPath instead and an error wrapping ErrConflict names that file.
+// Later changes are kept in memory only. Calls after the first do nothing.
+func (st *Store) Close() error {
+	st.writeMu.Lock()
+	if st.closed {
+		st.writeMu.Unlock()
+		return nil
+	}
+	st.closed = true
+	if st.timer != nil {
+		st.timer.Stop()
+		st.timer = nil
+	}
+	st.writeMu.Unlock()
+
+	st.watchMu.Lock()
+	if st.watcher != nil {
+		st.watcher.Stop()
+		st.watcher = nil
+	}
+	st.watchMu.Unlock()
+
+	err := st.Flush()
+	if errors.Is(err, ErrConflict) {
+		return st.saveUnsaved()
+	}
+	return err
+}
+
+// UnsavedPath returns the file Store.Close keeps settings in that could not
+// be written to path: goDrawer-settings.toml has
+// goDrawer-settings.unsaved.toml.
+func UnsavedPath(path string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + ".unsaved" + ext
+}
+
+// saveUnsaved writes the current settings to UnsavedPath, so that changes
+// conflicting with an edit on disk are not lost.
+func (st *Store) saveUnsaved() error {
+	unsaved := UnsavedPath(st.path)
+	data, err := encodeDelta(st.Snapshot())
+	if err != nil {
+		return err
+	}
+	if err := replaceFile(unsaved, data, false); err != nil {
+		return fmt.Errorf("%w, and the unsaved settings were lost: %w", ErrConflict, err)
+	}
+	return fmt.Errorf("%w; the unsaved settings were written to %s", ErrConflict, unsaved)
+}
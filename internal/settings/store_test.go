@@ -0,0 +1,194 @@
+package settings
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestStore(t *testing.T, delay time.Duration) *Store {
+	t.Helper()
+	path := writeSettings(t, "schema_version = 2\n\n[window_position]\nx = 1\ny = 1\n")
+	s, _, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	st := NewStore(path, s, delay)
+	t.Cleanup(func() { st.Close() })
+	return st
+}
+
+func moveWindow(x int) func(*Settings) error {
+	return func(s *Settings) error {
+		s.WindowPosition.X = x
+		return nil
+	}
+}
+
+// waitFor polls cond until it holds or a second has passed.
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	for deadline := time.Now().Add(time.Second); time.Now().Before(deadline); time.Sleep(5 * time.Millisecond) {
+		if cond() {
+			return
+		}
+	}
+	t.Fatalf("timed out waiting for %s", what)
+}
+
+func TestStoreCoalescesSaves(t *testing.T) {
+	st := newTestStore(t, 50*time.Millisecond)
+
+	for x := 2; x <= 5; x++ {
+		if err := st.Update("move", moveWindow(x)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := readFile(t, st.Path()); !strings.Contains(got, "x = 1") {
+		t.Errorf("file written before the delay:\n%s", got)
+	}
+
+	waitFor(t, "the debounced save", func() bool { return st.Saved().WindowPosition.X == 5 })
+	if got := readFile(t, st.Path()); !strings.Contains(got, "x = 5") {
+		t.Errorf("file after the save:\n%s", got)
+	}
+	// One write rotates the previous file into one backup.
+	if backups := backupPaths(st.Path()); len(backups) != 1 {
+		t.Errorf("backups = %v, want a single write", backups)
+	}
+}
+
+func TestStoreUpdateRollsBack(t *testing.T) {
+	st := newTestStore(t, time.Hour)
+	before := st.Snapshot()
+
+	var events []Event
+	st.Subscribe(func(e Event) { events = append(events, e) })
+
+	failure := errors.New("no")
+	err := st.Update("fail", func(s *Settings) error {
+		s.WindowPosition.X = 99
+		s.Drawers = append(s.Drawers, Drawer{ID: "d9", Name: "Half done"})
+		return failure
+	})
+	if !errors.Is(err, failure) {
+		t.Errorf("Update = %v, want the error of fn", err)
+	}
+	if st.Snapshot() != before || before.WindowPosition.X != 1 || len(before.Drawers) != 0 {
+		t.Errorf("failed update changed the settings: %+v", st.Snapshot())
+	}
+	if len(events) != 0 {
+		t.Errorf("failed update notified subscribers: %v", events)
+	}
+	if _, ok := st.UndoCause(); ok {
+		t.Errorf("failed update was added to the undo history")
+	}
+
+	if err := st.Update("move", moveWindow(7)); err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 1 || events[0].Cause != "move" || !events[0].Changes.Has(ChangeWindowPosition) {
+		t.Errorf("events = %+v, want one move", events)
+	}
+	if before.WindowPosition.X != 1 {
+		t.Errorf("update changed the previous snapshot")
+	}
+}
+
+func TestStoreDetectsConflicts(t *testing.T) {
+	st := newTestStore(t, time.Hour)
+	if err := st.Update("move", moveWindow(2)); err != nil {
+		t.Fatal(err)
+	}
+
+	edited := "schema_version = 2\n\n[window_position]\nx = 40\ny = 1\n"
+	if err := os.WriteFile(st.Path(), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := st.Flush(); !errors.Is(err, ErrConflict) {
+		t.Fatalf("Flush = %v, want ErrConflict", err)
+	}
+	if got := readFile(t, st.Path()); got != edited {
+		t.Errorf("Flush overwrote the edit:\n%s", got)
+	}
+
+	if err := st.Overwrite(); err != nil {
+		t.Fatal(err)
+	}
+	if got := readFile(t, st.Path()); !strings.Contains(got, "x = 2") {
+		t.Errorf("file after Overwrite:\n%s", got)
+	}
+	if err := st.Flush(); err != nil {
+		t.Errorf("Flush after Overwrite = %v", err)
+	}
+}
+
+func TestStoreReplaceAcceptsEdit(t *testing.T) {
+	st := newTestStore(t, time.Hour)
+
+	edited := "schema_version = 2\n\n[window_position]\nx = 40\ny = 1\n"
+	if err := os.WriteFile(st.Path(), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	remote, _, err := Reload(st.Path())
+	if err != nil {
+		t.Fatal(err)
+	}
+	st.Replace("reload", remote)
+
+	if err := st.Update("move", moveWindow(3)); err != nil {
+		t.Fatal(err)
+	}
+	if err := st.Flush(); err != nil {
+		t.Fatalf("Flush after Replace = %v", err)
+	}
+
+	// The edit on disk can be undone like any change.
+	if err := st.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if x := st.Snapshot().WindowPosition.X; x != 40 {
+		t.Errorf("after undoing the move x = %d, want 40", x)
+	}
+	if err := st.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	if x := st.Snapshot().WindowPosition.X; x != 1 {
+		t.Errorf("after undoing the reload x = %d, want 1", x)
+	}
+}
+
+func TestStoreCloseKeepsConflictingChanges(t *testing.T) {
+	st := newTestStore(t, time.Hour)
+	if err := st.Update("move", moveWindow(2)); err != nil {
+		t.Fatal(err)
+	}
+	edited := "schema_version = 2\n"
+	if err := os.WriteFile(st.Path(), []byte(edited), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	err := st.Close()
+	if !errors.Is(err, ErrConflict) || !strings.Contains(err.Error(), UnsavedPath(st.Path())) {
+		t.Fatalf("Close = %v, want ErrConflict naming the unsaved file", err)
+	}
+	if got := readFile(t, st.Path()); got != edited {
+		t.Errorf("Close overwrote the edit:\n%s", got)
+	}
+	if got := readFile(t, UnsavedPath(st.Path())); !strings.Contains(got, "x = 2") {
+		t.Errorf("unsaved file lacks the change:\n%s", got)
+	}
+
+	if err := st.Close(); err != nil {
+		t.Errorf("second Close = %v", err)
+	}
+}
+
+func TestUnsavedPath(t *testing.T) {
+	if got, want := UnsavedPath("/cfg/goDrawer-settings.toml"), "/cfg/goDrawer-settings.unsaved.toml"; got != want {
+		t.Errorf("UnsavedPath = %q, want %q", got, want)
+	}
+}
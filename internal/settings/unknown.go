@@ -0,0 +1,255 @@
+package settings
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// UnknownKey is a key in the settings file or the baseline that does not
+// belong to the schema and was therefore ignored.
+type UnknownKey struct {
+	Key string
+	// File is the path of the baseline when the key was read from there,
+	// and "" for keys of the settings file.
+	File string
+	// Line is the 1-based line the key appears on in its file, or 0 when
+	// it could not be located.
+	Line int
+	// Suggestion is the closest known key at the same level, if any is
+	// close enough to be a likely typo.
+	Suggestion string
+}
+
+func (k UnknownKey) String() string {
+	var b strings.Builder
+	if k.File != "" {
+		fmt.Fprintf(&b, "%s, ", k.File)
+	}
+	if k.Line > 0 {
+		fmt.Fprintf(&b, "line %d: ", k.Line)
+	}
+	b.WriteString(k.Key)
+	if k.Suggestion != "" {
+		fmt.Fprintf(&b, " (did you mean %s?)", k.Suggestion)
+	}
+	return b.String()
+}
+
+var arrayIndex = regexp.MustCompile(`\[\d+\]`)
+
+// unknownKeys turns the undecoded keys of a decode into UnknownKeys. Only
+// the outermost unknown key is reported: a misspelled table does not list
+// every key inside it as well. A key belongs to the settings file when its
+// migrated contents have it, and to the baseline otherwise. Line numbers
+// refer to the file the key belongs to as it was read; data is the
+// settings file.
+func unknownKeys(data, migrated []byte, base *baseline, undecoded []toml.Key) []UnknownKey {
+	if len(undecoded) == 0 {
+		return nil
+	}
+
+	seen := map[string]bool{}
+	for _, key := range undecoded {
+		seen[canonicalKey(key)] = true
+	}
+
+	doc, err := parseDocument(data)
+	if err != nil {
+		doc = nil
+	}
+
+	// Without a baseline every key is the settings file's.
+	var user map[string]any
+	var baseDoc *document
+	if base != nil {
+		user = map[string]any{}
+		if _, err := toml.Decode(string(migrated), &user); err != nil {
+			user = nil
+		}
+		if baseDoc, err = parseDocument(base.data); err != nil {
+			baseDoc = nil
+		}
+	}
+
+	var unknown []UnknownKey
+	for _, key := range undecoded {
+		if hasUnknownParent(key, seen) {
+			continue
+		}
+
+		found := UnknownKey{Key: canonicalKey(key), Suggestion: suggestKey(key)}
+		if base != nil && !hasKey(user, key) {
+			found.File, found.Line = base.path, baseDoc.lineOf(key)
+		} else {
+			found.Line = doc.lineOf(key)
+		}
+		unknown = append(unknown, found)
+	}
+
+	return unknown
+}
+
+// hasKey reports whether the raw document v has key. A key below an array
+// of tables is found in any of its tables.
+func hasKey(v any, key toml.Key) bool {
+	if len(key) == 0 {
+		return true
+	}
+	if tables, ok := tableList(v); ok {
+		for _, table := range tables {
+			if hasKey(table, key) {
+				return true
+			}
+		}
+		return false
+	}
+
+	table, ok := v.(map[string]any)
+	if !ok {
+		return false
+	}
+	next, ok := table[key[0]]
+	return ok && hasKey(next, key[1:])
+}
+
+func hasUnknownParent(key toml.Key, seen map[string]bool) bool {
+	for i := 1; i < len(key); i++ {
+		if seen[canonicalKey(key[:i])] {
+			return true
+		}
+	}
+	return false
+}
+
+// lineOf returns the 1-based line where key, or failing that its nearest
+// located parent, is defined. Keys inside inline tables are reported at the
+// line of the inline table.
+func (d *document) lineOf(key toml.Key) int {
+	if d == nil {
+		return 0
+	}
+
+	for n := len(key); n > 0; n-- {
+		path := canonicalKey(key[:n])
+		for _, t := range d.tables {
+			if t.header >= 0 && arrayIndex.ReplaceAllString(t.path, "") == path {
+				return t.header + 1
+			}
+		}
+		for _, k := range d.keys {
+			if arrayIndex.ReplaceAllString(k.path, "") == path {
+				return k.first + 1
+			}
+		}
+	}
+
+	return 0
+}
+
+// suggestKey returns the known key closest to the last part of key among
+// the keys allowed at the same level, or "" when none is close.
+func suggestKey(key toml.Key) string {
+	known := knownKeys(reflect.TypeFor[Settings](), key[:len(key)-1])
+	name := key[len(key)-1]
+
+	best, bestDistance := "", -1
+	for _, candidate := range known {
+		distance := levenshtein(strings.ToLower(name), candidate)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = candidate, distance
+		}
+	}
+
+	if bestDistance < 0 || bestDistance > max(2, len(name)/3) {
+		return ""
+	}
+
+	parent := canonicalKey(key[:len(key)-1])
+	return joinPath(parent, best)
+}
+
+// knownKeys lists the TOML keys of the struct reached by following parent
+// from t. It returns nil when parent leads into a map or unknown key.
+func knownKeys(t reflect.Type, parent []string) []string {
+	for _, part := range parent {
+		t = elemType(t)
+		if t.Kind() != reflect.Struct {
+			return nil
+		}
+		field, ok := fieldByKey(t, part)
+		if !ok {
+			return nil
+		}
+		t = field.Type
+	}
+
+	t = elemType(t)
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+
+	var keys []string
+	for i := 0; i < t.NumField(); i++ {
+		if name := tomlName(t.Field(i)); name != "" {
+			keys = append(keys, name)
+		}
+	}
+	return keys
+}
+
+func elemType(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	return t
+}
+
+func fieldByKey(t reflect.Type, key string) (reflect.StructField, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		if tomlName(t.Field(i)) == key {
+			return t.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func tomlName(field reflect.StructField) string {
+	if !field.IsExported() {
+		return ""
+	}
+	name, _, _ := strings.Cut(field.Tag.Get("toml"), ",")
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
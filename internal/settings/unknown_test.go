@@ -0,0 +1,133 @@
+package settings
+
+import (
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReadReportsUnknownKeys(t *testing.T) {
+	path := writeSettings(t, `schema_version = 2
+colour = "red"
+
+[startup]
+start_with_windos = true
+
+[[drawers]]
+id = "d1"
+name = "Docs"
+path = "/docs"
+
+[[drawers]]
+id = "d2"
+nmae = "Music"
+path = "/music"
+size = { width = 1, hieght = 2 }
+
+[thumbnail_sise]
+width = 50
+height = 50
+
+[extension_icon_map]
+".txt" = "text.ico"
+`)
+
+	_, report, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []UnknownKey{
+		{Key: "colour", Line: 2},
+		{Key: "startup.start_with_windos", Line: 5, Suggestion: "startup.start_with_windows"},
+		{Key: "drawers.nmae", Line: 14, Suggestion: "drawers.name"},
+		{Key: "drawers.size.hieght", Line: 16, Suggestion: "drawers.size.height"},
+		{Key: "thumbnail_sise", Line: 18, Suggestion: "thumbnail_size"},
+	}
+	if !slices.Equal(report.UnknownKeys, want) {
+		t.Errorf("UnknownKeys =\n%v\nwant\n%v", report.UnknownKeys, want)
+	}
+}
+
+func TestReadReportsUnknownBaselineKeys(t *testing.T) {
+	path := writeSettings(t, "schema_version = 2\n\n[theme]\nhue = 10\n")
+	base := path + ".baseline"
+	if err := os.WriteFile(base, []byte("schema_version = 2\n\n[startup]\nlocked = true\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvBaselinePath, base)
+
+	_, report, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []UnknownKey{
+		{Key: "startup.locked", File: base, Line: 4},
+		{Key: "theme.hue", Line: 4, Suggestion: "theme.h"},
+	}
+	got := slices.Clone(report.UnknownKeys)
+	slices.SortFunc(got, func(a, b UnknownKey) int { return strings.Compare(a.Key, b.Key) })
+	if !slices.Equal(got, want) {
+		t.Errorf("UnknownKeys =\n%v\nwant\n%v", got, want)
+	}
+}
+
+func TestUnknownKeyString(t *testing.T) {
+	tests := []struct {
+		key  UnknownKey
+		want string
+	}{
+		{UnknownKey{Key: "colour"}, "colour"},
+		{UnknownKey{Key: "colour", Line: 3}, "line 3: colour"},
+		{UnknownKey{Key: "theme.hue", Line: 4, Suggestion: "theme.h"}, "line 4: theme.hue (did you mean theme.h?)"},
+		{UnknownKey{Key: "startup.locked", File: "base.toml", Line: 2}, "base.toml, line 2: startup.locked"},
+	}
+
+	for _, tt := range tests {
+		if got := tt.key.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
+
+func TestSuggestKey(t *testing.T) {
+	tests := []struct {
+		key  []string
+		want string
+	}{
+		{[]string{"drawrs"}, "drawers"},
+		{[]string{"Theme"}, "theme"},
+		{[]string{"theme", "alpha"}, ""},
+		{[]string{"drawers", "view", "filtr"}, "drawers.view.filter"},
+		{[]string{"something_else"}, ""},
+		{[]string{"extension_icon_map", "txt"}, ""},
+	}
+
+	for _, tt := range tests {
+		if got := suggestKey(tt.key); got != tt.want {
+			t.Errorf("suggestKey(%q) = %q, want %q", tt.key, got, tt.want)
+		}
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"", "abc", 3},
+		{"theme", "theme", 0},
+		{"thme", "theme", 1},
+		{"hieght", "height", 2},
+		{"kitten", "sitting", 3},
+	}
+
+	for _, tt := range tests {
+		if got := levenshtein(tt.a, tt.b); got != tt.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
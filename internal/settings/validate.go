@@ -0,0 +1,287 @@
+package settings
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// ValidationMode controls how startup reacts to validation problems.
+type ValidationMode int
+
+const (
+	// Lenient reports problems and keeps running.
+	Lenient ValidationMode = iota
+	// Strict refuses to continue when any problem is found.
+	Strict
+)
+
+// FieldError describes a single problem with a settings value. Field is the
+// path of the offending key using TOML names, e.g. drawers[2].size.width.
+type FieldError struct {
+	Field   string
+	Message string
+}
+
+func (e *FieldError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Field, e.Message)
+}
+
+// ValidationError collects every problem found by Validate.
+type ValidationError struct {
+	Problems []*FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Problems) == 1 {
+		return "invalid settings: " + e.Problems[0].Error()
+	}
+	return fmt.Sprintf("invalid settings: %d problems, first: %v", len(e.Problems), e.Problems[0])
+}
+
+func (e *ValidationError) add(field, format string, args ...any) {
+	e.Problems = append(e.Problems, &FieldError{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the settings for values the UI cannot work with. It
+// returns nil or a *ValidationError listing every problem found.
+func (s *Settings) Validate() error {
+	result := &ValidationError{}
+
+	groupIDs := map[string]int{}
+	groupNames := map[string]int{}
+	for i, group := range s.Groups {
+		field := fmt.Sprintf("groups[%d]", i)
+
+		if group.ID == "" {
+			result.add(field+".id", "must not be empty")
+		} else if first, ok := groupIDs[group.ID]; ok {
+			result.add(field+".id", "duplicates groups[%d].id %q", first, group.ID)
+		} else {
+			groupIDs[group.ID] = i
+		}
+
+		name := strings.TrimSpace(group.Name)
+		if name == "" {
+			result.add(field+".name", "must not be empty")
+		} else if first, ok := groupNames[strings.ToLower(name)]; ok {
+			result.add(field+".name", "duplicates groups[%d].name %q", first, group.Name)
+		} else {
+			groupNames[strings.ToLower(name)] = i
+		}
+	}
+
+	profileIDs := map[string]int{}
+	profileNames := map[string]int{}
+	for i, profile := range s.Profiles {
+		field := fmt.Sprintf("profiles[%d]", i)
+
+		if profile.ID == "" {
+			result.add(field+".id", "must not be empty")
+		} else if first, ok := profileIDs[profile.ID]; ok {
+			result.add(field+".id", "duplicates profiles[%d].id %q", first, profile.ID)
+		} else {
+			profileIDs[profile.ID] = i
+		}
+
+		name := strings.TrimSpace(profile.Name)
+		if name == "" {
+			result.add(field+".name", "must not be empty")
+		} else if strings.EqualFold(name, BaseProfileName) {
+			result.add(field+".name", "%q is reserved for the shared base", BaseProfileName)
+		} else if first, ok := profileNames[strings.ToLower(name)]; ok {
+			result.add(field+".name", "duplicates profiles[%d].name %q", first, profile.Name)
+		} else {
+			profileNames[strings.ToLower(name)] = i
+		}
+
+		if profile.Theme != nil {
+			validateTheme(result, field+".theme", *profile.Theme)
+		}
+		s.validateIconMap(result, field+".extension_icon_map", profile.ExtensionIconMap)
+	}
+
+	if s.ActiveProfile != "" {
+		if _, ok := profileIDs[s.ActiveProfile]; !ok {
+			result.add("active_profile", "refers to unknown profile %q", s.ActiveProfile)
+		}
+	}
+
+	ids := map[string]int{}
+	for i, drawer := range s.Drawers {
+		field := fmt.Sprintf("drawers[%d]", i)
+
+		if drawer.ID == "" {
+			result.add(field+".id", "must not be empty")
+		} else if first, ok := ids[drawer.ID]; ok {
+			result.add(field+".id", "duplicates drawers[%d].id %q", first, drawer.ID)
+		} else {
+			ids[drawer.ID] = i
+		}
+
+		// Names and paths only need to be unique among drawers that can be
+		// shown together.
+		name := strings.TrimSpace(drawer.Name)
+		if name == "" {
+			result.add(field+".name", "must not be empty")
+		} else if first := s.firstSharedDrawer(i, func(other Drawer) bool {
+			return strings.EqualFold(strings.TrimSpace(other.Name), name)
+		}); first >= 0 {
+			result.add(field+".name", "duplicates drawers[%d].name %q", first, drawer.Name)
+		}
+
+		if strings.TrimSpace(drawer.Path) == "" {
+			result.add(field+".path", "must not be empty")
+		} else if err := s.checkPathTokens(drawer.Path); err != nil {
+			result.add(field+".path", "%v", err)
+		} else if first := s.firstSharedDrawer(i, func(other Drawer) bool {
+			return samePath(s.ResolvePath(other.Path), s.ResolvePath(drawer.Path))
+		}); first >= 0 {
+			result.add(field+".path", "duplicates drawers[%d].path %q", first, drawer.Path)
+		}
+
+		if drawer.Group != "" {
+			if _, ok := groupIDs[drawer.Group]; !ok {
+				result.add(field+".group", "refers to unknown group %q", drawer.Group)
+			}
+		}
+
+		if drawer.Profile != "" {
+			if _, ok := profileIDs[drawer.Profile]; !ok {
+				result.add(field+".profile", "refers to unknown profile %q", drawer.Profile)
+			}
+		}
+
+		validateSize(result, field+".size", drawer.Size)
+		if drawer.View != nil {
+			validateView(result, field+".view", *drawer.View)
+		}
+	}
+
+	validateSize(result, "thumbnail_size", s.ThumbnailSize)
+	validateTheme(result, "theme", s.Theme)
+	s.validateIconMap(result, "extension_icon_map", s.ExtensionIconMap)
+	validateRange(result, "control.port", s.Control.Port, 0, 65535)
+
+	if len(result.Problems) == 0 {
+		return nil
+	}
+	return result
+}
+
+// firstSharedDrawer returns the index of the first drawer before i that is
+// shown together with drawer i and matches, or -1.
+func (s *Settings) firstSharedDrawer(i int, match func(Drawer) bool) int {
+	for j := 0; j < i; j++ {
+		if sharesProfile(s.Drawers[j].Profile, s.Drawers[i].Profile) && match(s.Drawers[j]) {
+			return j
+		}
+	}
+	return -1
+}
+
+// ValidateTheme checks the values of a theme on its own. It returns nil or
+// a *ValidationError whose fields are the keys of the theme: h, s, l and a.
+func ValidateTheme(theme Theme) error {
+	result := &ValidationError{}
+	validateTheme(result, "", theme)
+	if len(result.Problems) == 0 {
+		return nil
+	}
+	return result
+}
+
+func validateTheme(result *ValidationError, field string, theme Theme) {
+	if field != "" {
+		field += "."
+	}
+	validateRange(result, field+"h", theme.Hue, 0, 360)
+	validateRange(result, field+"s", theme.Saturation, 0, 100)
+	validateRange(result, field+"l", theme.Lightness, 0, 100)
+	validateRange(result, field+"a", theme.Alpha, 0, 100)
+}
+
+func (s *Settings) validateIconMap(result *ValidationError, field string, iconMap map[string]string) {
+	exts := make([]string, 0, len(iconMap))
+	for ext := range iconMap {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	for _, ext := range exts {
+		icon := iconMap[ext]
+		entry := fmt.Sprintf("%s.%q", field, ext)
+		if !strings.HasPrefix(ext, ".") {
+			result.add(entry, "extension must start with a dot")
+		}
+		if strings.TrimSpace(icon) == "" {
+			result.add(entry, "icon path must not be empty")
+		} else if err := s.checkPathTokens(icon); err != nil {
+			result.add(entry, "%v", err)
+		}
+	}
+}
+
+func validateSize(result *ValidationError, field string, size Size) {
+	if size.Width <= 0 {
+		result.add(field+".width", "must be positive, got %d", size.Width)
+	}
+	if size.Height <= 0 {
+		result.add(field+".height", "must be positive, got %d", size.Height)
+	}
+}
+
+func validateView(result *ValidationError, field string, view ViewState) {
+	if view.SortColumn < 0 {
+		result.add(field+".sort_column", "must not be negative, got %d", view.SortColumn)
+	}
+	switch view.SortOrder {
+	case "", SortAscending, SortDescending:
+	default:
+		result.add(field+".sort_order", "must be %q or %q, got %q", SortAscending, SortDescending, view.SortOrder)
+	}
+	for i, width := range view.ColumnWidths {
+		if width <= 0 {
+			result.add(fmt.Sprintf("%s.column_widths[%d]", field, i), "must be positive, got %d", width)
+		}
+	}
+	if view.Subpath != "" {
+		clean := filepath.Clean(view.Subpath)
+		if filepath.IsAbs(clean) || filepath.VolumeName(clean) != "" || clean == ".." || strings.HasPrefix(clean, ".."+string(filepath.Separator)) {
+			result.add(field+".subpath", "must be relative to the drawer path, got %q", view.Subpath)
+		}
+	}
+}
+
+func validateRange(result *ValidationError, field string, value, min, max int) {
+	if value < min || value > max {
+		result.add(field, "must be between %d and %d, got %d", min, max, value)
+	}
+}
+
+// PrintValidation prints a summary of the problems in err, which is
+// expected to come from Validate.
+func PrintValidation(err error, mode ValidationMode) {
+	FprintValidation(os.Stdout, err, mode)
+}
+
+// FprintValidation is PrintValidation writing to w.
+func FprintValidation(w io.Writer, err error, mode ValidationMode) {
+	verr, ok := err.(*ValidationError)
+	if !ok || verr == nil {
+		return
+	}
+
+	label := "warning"
+	if mode == Strict {
+		label = "error"
+	}
+
+	fmt.Fprintf(w, ":: Settings Validation (%d problems) ::\n", len(verr.Problems))
+	for _, problem := range verr.Problems {
+		fmt.Fprintf(w, "  %s: %s\n", label, problem)
+	}
+	fmt.Fprintln(w)
+}
@@ -0,0 +1,95 @@
+package settings
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"sync"
+	"time"
+)
+
+// Fingerprint returns a digest of the file contents at path. A missing file
+// has the empty fingerprint.
+func Fingerprint(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return "", nil
+		}
+		return "", err
+	}
+
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// Watcher polls a settings file and reports content changes it has not
+// been told about through Acknowledge.
+type Watcher struct {
+	path     string
+	interval time.Duration
+	onChange func(fingerprint string)
+
+	mu    sync.Mutex
+	known string
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// Watch starts polling path every interval. onChange runs on the polling
+// goroutine whenever the file contents differ from the last known state.
+func Watch(path string, interval time.Duration, onChange func(fingerprint string)) *Watcher {
+	w := &Watcher{
+		path:     path,
+		interval: interval,
+		onChange: onChange,
+		stop:     make(chan struct{}),
+	}
+	w.known, _ = Fingerprint(path)
+
+	go w.loop()
+	return w
+}
+
+// Acknowledge records fingerprint as already known, typically right after
+// the application wrote the file itself.
+func (w *Watcher) Acknowledge(fingerprint string) {
+	w.mu.Lock()
+	w.known = fingerprint
+	w.mu.Unlock()
+}
+
+// Stop ends polling. It is safe to call more than once.
+func (w *Watcher) Stop() {
+	w.stopOnce.Do(func() { close(w.stop) })
+}
+
+func (w *Watcher) loop() {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.stop:
+			return
+		case <-ticker.C:
+		}
+
+		current, err := Fingerprint(w.path)
+		if err != nil || current == "" {
+			continue
+		}
+
+		w.mu.Lock()
+		changed := current != w.known
+		if changed {
+			w.known = current
+		}
+		w.mu.Unlock()
+
+		if changed {
+			w.onChange(current)
+		}
+	}
+}
@@ -0,0 +1,95 @@
+// Package theme turns the HSLA theme of the settings into the colors the
+// windows are painted with.
+package theme
+
+import (
+	"image/color"
+	"math"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// Palette is the color set computed from a theme.
+type Palette struct {
+	Accent        color.RGBA
+	AccentLight   color.RGBA
+	AccentDark    color.RGBA
+	Background    color.RGBA
+	Surface       color.RGBA
+	SurfaceLight  color.RGBA
+	TextPrimary   color.RGBA
+	TextSecondary color.RGBA
+	// Alpha is the window opacity, 0-255.
+	Alpha byte
+}
+
+// NewPalette computes the palette of theme. Components out of range are
+// clamped.
+func NewPalette(theme settings.Theme) Palette {
+	h := clamp(float64(theme.Hue)/360.0, 0, 1)
+	s := clamp(float64(theme.Saturation)/100.0, 0, 1)
+	l := clamp(float64(theme.Lightness)/100.0, 0, 1)
+
+	return Palette{
+		Accent:        HSLA(h, s, l, 1),
+		AccentLight:   HSLA(h, s, clamp(l+0.18, 0, 1), 1),
+		AccentDark:    HSLA(h, s, clamp(l-0.18, 0, 1), 1),
+		Background:    HSLA(h, clamp(s*0.40, 0, 1), clamp(l-0.18, 0, 1), 1),
+		Surface:       HSLA(h, clamp(s*0.45, 0, 1), clamp(l-0.05, 0, 1), 1),
+		SurfaceLight:  HSLA(h, clamp(s*0.35, 0, 1), clamp(l+0.07, 0, 1), 1),
+		TextPrimary:   color.RGBA{R: 245, G: 245, B: 245, A: 255},
+		TextSecondary: color.RGBA{R: 215, G: 215, B: 215, A: 255},
+		Alpha:         byte(clamp(float64(theme.Alpha)/100.0, 0, 1) * 255),
+	}
+}
+
+// HSLA converts a color given as hue, saturation, lightness and alpha, all
+// in the range 0-1, to RGBA.
+func HSLA(h, s, l, a float64) color.RGBA {
+	var r, g, b float64
+
+	if s == 0 {
+		r, g, b = l, l, l
+	} else {
+		var q float64
+		if l < 0.5 {
+			q = l * (1 + s)
+		} else {
+			q = l + s - l*s
+		}
+		p := 2*l - q
+		r = hueToRGB(p, q, h+1.0/3.0)
+		g = hueToRGB(p, q, h)
+		b = hueToRGB(p, q, h-1.0/3.0)
+	}
+
+	return color.RGBA{
+		R: uint8(clamp(r, 0, 1) * 255),
+		G: uint8(clamp(g, 0, 1) * 255),
+		B: uint8(clamp(b, 0, 1) * 255),
+		A: uint8(clamp(a, 0, 1) * 255),
+	}
+}
+
+func hueToRGB(p, q, t float64) float64 {
+	if t < 0 {
+		t += 1
+	}
+	if t > 1 {
+		t -= 1
+	}
+	switch {
+	case t < 1.0/6.0:
+		return p + (q-p)*6*t
+	case t < 1.0/2.0:
+		return q
+	case t < 2.0/3.0:
+		return p + (q-p)*(2.0/3.0-t)*6
+	default:
+		return p
+	}
+}
+
+func clamp(value, min, max float64) float64 {
+	return math.Max(min, math.Min(max, value))
+}
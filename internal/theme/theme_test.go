@@ -0,0 +1,101 @@
+package theme
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+func TestHSLA(t *testing.T) {
+	tests := []struct {
+		name       string
+		h, s, l, a float64
+		want       color.RGBA
+	}{
+		{"red", 0, 1, 0.5, 1, color.RGBA{255, 0, 0, 255}},
+		{"green", 1.0 / 3.0, 1, 0.5, 1, color.RGBA{0, 255, 0, 255}},
+		{"blue", 2.0 / 3.0, 1, 0.5, 1, color.RGBA{0, 0, 255, 255}},
+		{"full turn is red", 1, 1, 0.5, 1, color.RGBA{255, 0, 0, 255}},
+		{"no saturation is gray", 0.3, 0, 0.5, 1, color.RGBA{127, 127, 127, 255}},
+		{"black", 0.6, 1, 0, 1, color.RGBA{0, 0, 0, 255}},
+		{"white", 0.6, 1, 1, 1, color.RGBA{255, 255, 255, 255}},
+		{"transparent", 0, 1, 0.5, 0, color.RGBA{255, 0, 0, 0}},
+		{"half alpha", 0, 1, 0.5, 0.5, color.RGBA{255, 0, 0, 127}},
+		{"alpha above range", 0, 1, 0.5, 2, color.RGBA{255, 0, 0, 255}},
+		{"alpha below range", 0, 1, 0.5, -1, color.RGBA{255, 0, 0, 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := HSLA(tt.h, tt.s, tt.l, tt.a); got != tt.want {
+				t.Errorf("HSLA(%v, %v, %v, %v) = %v, want %v", tt.h, tt.s, tt.l, tt.a, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNewPaletteAlpha(t *testing.T) {
+	tests := []struct {
+		alpha int
+		want  byte
+	}{
+		{0, 0},
+		{50, 127},
+		{100, 255},
+		{150, 255},
+		{-10, 0},
+	}
+
+	for _, tt := range tests {
+		theme := settings.Theme{Hue: 192, Saturation: 40, Lightness: 36, Alpha: tt.alpha}
+		if got := NewPalette(theme).Alpha; got != tt.want {
+			t.Errorf("NewPalette(alpha %d).Alpha = %d, want %d", tt.alpha, got, tt.want)
+		}
+	}
+}
+
+func TestNewPaletteClampsComponents(t *testing.T) {
+	tests := []struct {
+		name      string
+		got, want settings.Theme
+	}{
+		{"hue above range", settings.Theme{Hue: 400, Saturation: 50, Lightness: 50}, settings.Theme{Hue: 360, Saturation: 50, Lightness: 50}},
+		{"saturation above range", settings.Theme{Hue: 100, Saturation: 150, Lightness: 50}, settings.Theme{Hue: 100, Saturation: 100, Lightness: 50}},
+		{"lightness below range", settings.Theme{Hue: 100, Saturation: 50, Lightness: -5}, settings.Theme{Hue: 100, Saturation: 50, Lightness: 0}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, want := NewPalette(tt.got), NewPalette(tt.want); got != want {
+				t.Errorf("NewPalette(%+v) = %+v, want %+v", tt.got, got, want)
+			}
+		})
+	}
+}
+
+func TestNewPaletteShades(t *testing.T) {
+	p := NewPalette(settings.Theme{Hue: 0, Saturation: 100, Lightness: 50, Alpha: 100})
+
+	if p.Accent != (color.RGBA{255, 0, 0, 255}) {
+		t.Errorf("Accent = %v, want pure red", p.Accent)
+	}
+	if luma(p.AccentDark) >= luma(p.Accent) || luma(p.Accent) >= luma(p.AccentLight) {
+		t.Errorf("accents not ordered dark < accent < light: %v %v %v", p.AccentDark, p.Accent, p.AccentLight)
+	}
+
+	// At the ends of the lightness range the shades clamp instead of
+	// wrapping around.
+	dark := NewPalette(settings.Theme{Hue: 0, Saturation: 100, Lightness: 0})
+	if dark.AccentDark != (color.RGBA{0, 0, 0, 255}) {
+		t.Errorf("AccentDark at lightness 0 = %v, want black", dark.AccentDark)
+	}
+	light := NewPalette(settings.Theme{Hue: 0, Saturation: 100, Lightness: 100})
+	if light.AccentLight != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("AccentLight at lightness 100 = %v, want white", light.AccentLight)
+	}
+}
+
+func luma(c color.RGBA) int {
+	return 299*int(c.R) + 587*int(c.G) + 114*int(c.B)
+}
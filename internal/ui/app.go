@@ -2,17 +2,23 @@ package ui
 
 import (
 	"log"
-	"path/filepath"
 
+	"github.com/deadlyedge/goDrawer/internal/assets"
+	"github.com/deadlyedge/goDrawer/internal/control"
+	"github.com/deadlyedge/goDrawer/internal/platform"
 	"github.com/deadlyedge/goDrawer/internal/settings"
 	"github.com/lxn/walk"
-	"github.com/lxn/win"
 )
 
 type App struct {
-	settingsPath string
-	config       *settings.Settings
-	palette      palette
+	// store owns the settings; config is its latest snapshot as seen by the
+	// UI thread and must not be modified.
+	store       *settings.Store
+	config      *settings.Settings
+	unsubscribe func()
+	reloading   bool
+	palette     palette
+	platform    platform.Services
 
 	mainWindow      *walk.MainWindow
 	headerComposite *walk.Composite
@@ -22,8 +28,10 @@ type App struct {
 	settingsButton  *walk.PushButton
 	addDrawerButton *walk.PushButton
 	drawerItems     []*drawerItemView
+	groupHeaders    []*groupHeaderView
 	drawerWindows   []*drawerWindow
 	hoveredDrawer   *drawerItemView
+	drag            *drawerDrag
 
 	brushes struct {
 		AccentLight  *walk.SolidColorBrush
@@ -36,14 +44,11 @@ type App struct {
 
 	buttonStyles map[*walk.PushButton]buttonStyle
 
-	notifyIcon  *walk.NotifyIcon
-	trayActions struct {
-		ShowHide *walk.Action
-	}
+	tray platform.Tray
 
-	images struct {
-		Tray *walk.Icon
-	}
+	// control is the running control API, started with controlConfig.
+	control       *control.Server
+	controlConfig settings.Control
 }
 
 type buttonStyle struct {
@@ -52,47 +57,42 @@ type buttonStyle struct {
 }
 
 // MainWindow bootstraps the UI using the provided settings.
-func MainWindow(cfg *settings.Settings, settingsPath string) {
+func MainWindow(cfg *settings.Settings, settingsPath string, opts Options) {
 	app := &App{
-		settingsPath: settingsPath,
-		config:       cfg,
+		store:    settings.NewStore(settingsPath, cfg, settings.DefaultSaveDelay),
+		config:   cfg,
+		platform: platform.Native(),
 	}
 
-	if err := app.run(); err != nil {
+	if err := app.run(opts); err != nil {
 		log.Fatalf("failed to launch UI: %v", err)
 	}
 }
 
-func (a *App) run() error {
-	if err := ensureBrandFont(filepath.Join("assets", "fonts", "Lobster-Regular.ttf")); err != nil {
+func (a *App) run(opts Options) error {
+	if err := a.platform.Fonts.Register(assets.Path(assets.BrandFont)); err != nil {
 		log.Printf("warn: failed to register brand font: %v", err)
 	}
 
-	a.palette = buildPalette(a.config.Theme)
+	a.palette = buildPalette(a.config.ActiveTheme())
 	a.buttonStyles = map[*walk.PushButton]buttonStyle{}
 
 	if err := a.createBrushes(); err != nil {
 		return err
 	}
 
-	if err := a.loadImages(); err != nil {
-		log.Printf("warn: failed to load UI images: %v", err)
-	}
-
 	if err := a.createMainWindow(); err != nil {
 		return err
 	}
 
 	a.mainWindow.Disposing().Attach(func() {
-		if a.notifyIcon != nil {
-			a.notifyIcon.Dispose()
-			a.notifyIcon = nil
-		}
-		a.disposeImages()
+		a.stopControl()
+		a.stopSettingsSync()
+		a.closeTray()
 		a.disposeBrushes()
 	})
 
-	if err := a.setupNotifyIcon(); err != nil {
+	if err := a.setupTray(); err != nil {
 		log.Printf("warn: failed to create system tray icon: %v", err)
 	}
 
@@ -102,7 +102,15 @@ func (a *App) run() error {
 		return err
 	}
 
+	a.startSettingsSync()
+	a.syncAutostart()
+	a.syncControl()
+	a.handleRequest(opts.Startup)
+	a.serveRequests(opts.Requests)
+
 	a.mainWindow.Run()
+	a.stopControl()
+	a.stopSettingsSync()
 	return nil
 }
 
@@ -161,25 +169,6 @@ func (a *App) disposeBrushes() {
 	}{}
 }
 
-func (a *App) loadImages() error {
-	if a.images.Tray == nil {
-		if icon, err := walk.NewIconFromFile(filepath.Join("assets", "drawer.icon.4.ico")); err == nil {
-			a.images.Tray = icon
-		} else {
-			log.Printf("warn: failed to load tray icon: %v", err)
-		}
-	}
-
-	return nil
-}
-
-func (a *App) disposeImages() {
-	if a.images.Tray != nil {
-		a.images.Tray.Dispose()
-		a.images.Tray = nil
-	}
-}
-
 func (a *App) applyPalette() {
 	if a.mainWindow != nil && a.brushes.Background != nil {
 		a.mainWindow.SetBackground(a.brushes.Background)
@@ -205,6 +194,10 @@ func (a *App) applyPalette() {
 		item.applyPalette(item == a.hoveredDrawer)
 	}
 
+	for _, header := range a.groupHeaders {
+		header.applyPalette()
+	}
+
 	a.applyButtonStyle(a.settingsButton)
 	a.applyButtonStyle(a.addDrawerButton)
 
@@ -237,18 +230,23 @@ func (a *App) applyButtonStyle(btn *walk.PushButton) {
 }
 
 func (a *App) persistDrawerSettings(updated settings.Drawer) {
-	if a.config == nil {
-		return
-	}
-
-	for i := range a.config.Drawers {
-		if a.config.Drawers[i].Path == updated.Path {
-			a.config.Drawers[i] = updated
-			break
+	updated = updated.Clone()
+
+	// Only what the drawer window owns is taken over; name, path and group
+	// may have been edited from the main window while it was open.
+	err := a.store.Adjust("drawer window", func(s *settings.Settings) error {
+		i := s.DrawerIndex(updated.ID)
+		if i < 0 {
+			return nil
 		}
-	}
-
-	if err := settings.Update(a.settingsPath, a.config); err != nil {
+		s.Drawers[i].Size = updated.Size
+		s.Drawers[i].View = updated.View
+		if s.Drawers[i].Path != updated.Path && updated.View != nil {
+			updated.View.Subpath = ""
+		}
+		return nil
+	})
+	if err != nil {
 		log.Printf("failed to persist drawer settings: %v", err)
 	}
 }
@@ -283,59 +281,6 @@ func (a *App) refreshButtonStyles() {
 	}
 }
 
-func (a *App) setupNotifyIcon() error {
-	if a.mainWindow == nil {
-		return nil
-	}
-
-	ni, err := walk.NewNotifyIcon(a.mainWindow)
-	if err != nil {
-		return err
-	}
-
-	if a.images.Tray != nil {
-		ni.SetIcon(a.images.Tray)
-	}
-	ni.SetToolTip("goDrawer")
-
-	titleAction := walk.NewAction()
-	titleAction.SetText("goDrawer")
-	titleAction.Triggered().Attach(func() {
-		a.showMainWindow()
-	})
-
-	a.trayActions.ShowHide = walk.NewAction()
-	a.trayActions.ShowHide.SetText("Hide app")
-	a.trayActions.ShowHide.Triggered().Attach(func() {
-		a.toggleMainWindowVisibility()
-	})
-
-	exitAction := walk.NewAction()
-	exitAction.SetText("Exit app")
-	exitAction.Triggered().Attach(func() {
-		walk.App().Exit(0)
-	})
-
-	menu := ni.ContextMenu()
-	menu.Actions().Add(titleAction)
-	menu.Actions().Add(a.trayActions.ShowHide)
-	menu.Actions().Add(exitAction)
-
-	ni.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
-		if button == walk.LeftButton {
-			a.toggleMainWindowVisibility()
-		}
-	})
-
-	if err := ni.SetVisible(true); err != nil {
-		ni.Dispose()
-		return err
-	}
-
-	a.notifyIcon = ni
-	return nil
-}
-
 func (a *App) showMainWindow() {
 	if a.mainWindow == nil {
 		return
@@ -343,9 +288,7 @@ func (a *App) showMainWindow() {
 	a.mainWindow.Show()
 	a.mainWindow.BringToTop()
 	a.mainWindow.SetFocus()
-	if a.trayActions.ShowHide != nil {
-		a.trayActions.ShowHide.SetText("Hide app")
-	}
+	a.updateTray()
 }
 
 func (a *App) hideMainWindow() {
@@ -353,9 +296,7 @@ func (a *App) hideMainWindow() {
 		return
 	}
 	a.mainWindow.Hide()
-	if a.trayActions.ShowHide != nil {
-		a.trayActions.ShowHide.SetText("Show app")
-	}
+	a.updateTray()
 }
 
 func (a *App) toggleMainWindowVisibility() {
@@ -380,24 +321,8 @@ func (a *App) drawerItemAt(x, y int) *drawerItemView {
 }
 
 func (a *App) isCursorOverDrawerContainer() bool {
-	if a.drawerContainer == nil {
-		return false
-	}
-
-	var pt win.POINT
-	if !win.GetCursorPos(&pt) {
-		return false
-	}
-
-	client := pt
-	if !win.ScreenToClient(a.drawerContainer.Handle(), &client) {
-		return false
-	}
-
-	bounds := a.drawerContainer.ClientBounds()
-	x := int(client.X)
-	y := int(client.Y)
-	return x >= bounds.X && x < bounds.X+bounds.Width && y >= bounds.Y && y < bounds.Y+bounds.Height
+	_, _, inside := a.cursorInDrawerContainer()
+	return inside
 }
 
 func (a *App) updateTheme(theme settings.Theme) error {
@@ -412,14 +337,14 @@ func (a *App) updateTheme(theme settings.Theme) error {
 	a.applyPalette()
 
 	if a.mainWindow != nil {
-		if err := makeWindowSemiTransparent(a.mainWindow, a.palette.Alpha); err != nil {
+		if err := a.platform.Windows.SetOpacity(handle(a.mainWindow), a.palette.Alpha); err != nil {
 			log.Printf("failed to apply transparency to main window: %v", err)
 		}
 	}
 
 	for _, dw := range a.drawerWindows {
 		if dw != nil && dw.window != nil {
-			if err := makeWindowSemiTransparent(dw.window, a.palette.Alpha); err != nil {
+			if err := a.platform.Windows.SetOpacity(handle(dw.window), a.palette.Alpha); err != nil {
 				log.Printf("failed to apply transparency to drawer window: %v", err)
 			}
 			dw.applyTheme()
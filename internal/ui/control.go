@@ -0,0 +1,191 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/deadlyedge/goDrawer/internal/control"
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// controlTimeout bounds how long an API request waits for the UI thread.
+const controlTimeout = 5 * time.Second
+
+// syncControl starts, restarts or stops the control API to match the
+// settings. An enabled API without a token first gets one; the store
+// subscriber then calls back in with it.
+func (a *App) syncControl() {
+	cfg := a.config.Control
+	if a.control != nil && a.controlConfig == cfg {
+		return
+	}
+	a.stopControl()
+	if !cfg.Enabled {
+		return
+	}
+
+	if cfg.Token == "" {
+		if err := a.store.Adjust("control token", func(s *settings.Settings) error {
+			s.Control.Token = settings.NewControlToken()
+			return nil
+		}); err != nil {
+			log.Printf("failed to create control API token: %v", err)
+		}
+		return
+	}
+
+	server, err := control.Start(cfg.PortOrDefault(), cfg.Token, &controller{app: a})
+	if err != nil {
+		log.Printf("warn: control API unavailable: %v", err)
+		return
+	}
+	a.control, a.controlConfig = server, cfg
+	log.Printf("control API listening on http://%s", server.Addr())
+}
+
+func (a *App) stopControl() {
+	if a.control == nil {
+		return
+	}
+	if err := a.control.Close(); err != nil {
+		log.Printf("failed to stop control API: %v", err)
+	}
+	a.control, a.controlConfig = nil, settings.Control{}
+}
+
+// controller carries out control API requests on the UI thread.
+type controller struct {
+	app *App
+}
+
+// do runs fn on the UI thread and waits for it.
+func (c *controller) do(fn func() error) error {
+	done := make(chan error, 1)
+	c.app.mainWindow.Synchronize(func() { done <- fn() })
+
+	select {
+	case err := <-done:
+		return err
+	case <-time.After(controlTimeout):
+		return errors.New("the UI did not respond in time")
+	}
+}
+
+func (c *controller) Drawers() ([]control.Drawer, error) {
+	var drawers []control.Drawer
+	err := c.do(func() error {
+		s := c.app.config
+		drawers = make([]control.Drawer, len(s.Drawers))
+		for i, drawer := range s.Drawers {
+			info := control.Drawer{
+				ID:           drawer.ID,
+				Name:         drawer.Name,
+				Path:         drawer.Path,
+				ResolvedPath: s.ResolvePath(drawer.Path),
+				Group:        drawer.Group,
+				Profile:      drawer.Profile,
+				Confined:     drawer.Confined,
+				Visible:      s.DrawerVisible(drawer),
+			}
+			if dw := c.app.drawerWindowByID(drawer.ID); dw != nil {
+				info.Open = true
+				info.Location = dw.currentPath
+			}
+			drawers[i] = info
+		}
+		return nil
+	})
+	return drawers, err
+}
+
+func (c *controller) OpenDrawer(ref string) error {
+	return c.do(func() error {
+		_, err := c.app.openDrawerByRef(ref)
+		return err
+	})
+}
+
+func (c *controller) CloseDrawer(ref string) error {
+	return c.do(func() error {
+		drawer, ok := findVisibleDrawer(c.app.config, ref)
+		if !ok {
+			return control.ErrNotFound
+		}
+		if dw := c.app.drawerWindowByID(drawer.ID); dw != nil {
+			dw.close()
+		}
+		return nil
+	})
+}
+
+func (c *controller) Navigate(ref, subpath string) error {
+	subpath = filepath.FromSlash(subpath)
+	if subpath != "" && !filepath.IsLocal(subpath) {
+		return &control.BadRequest{Err: fmt.Errorf("subpath %q is not inside the drawer folder", subpath)}
+	}
+
+	return c.do(func() error {
+		dw, err := c.app.openDrawerByRef(ref)
+		if err != nil {
+			return err
+		}
+
+		target := filepath.Join(dw.root, subpath)
+		if info, err := os.Stat(target); err != nil || !info.IsDir() {
+			return &control.BadRequest{Err: fmt.Errorf("%s is not a folder", target)}
+		}
+		return dw.navigateTo(target)
+	})
+}
+
+func (c *controller) ToggleMainWindowVisibility() (bool, error) {
+	var visible bool
+	err := c.do(func() error {
+		c.app.toggleMainWindowVisibility()
+		visible = c.app.mainWindow.Visible()
+		return nil
+	})
+	return visible, err
+}
+
+func (c *controller) ReloadSettings() error {
+	return c.do(func() error {
+		c.app.onSettingsFileChanged()
+		return nil
+	})
+}
+
+func (c *controller) SetTheme(update control.ThemeUpdate) (settings.Theme, error) {
+	var theme settings.Theme
+	err := c.do(func() error {
+		theme = update.Apply(c.app.config.ActiveTheme())
+		if err := settings.ValidateTheme(theme); err != nil {
+			return &control.BadRequest{Err: err}
+		}
+		return c.app.store.Update("theme", func(s *settings.Settings) error {
+			s.SetActiveTheme(theme)
+			return nil
+		})
+	})
+	return theme, err
+}
+
+// openDrawerByRef opens the drawer of the active profile with the given ID
+// or name and returns its window.
+func (a *App) openDrawerByRef(ref string) (*drawerWindow, error) {
+	drawer, ok := findVisibleDrawer(a.config, ref)
+	if !ok {
+		return nil, control.ErrNotFound
+	}
+
+	a.openDrawer(drawer)
+	dw := a.drawerWindowByID(drawer.ID)
+	if dw == nil {
+		return nil, fmt.Errorf("failed to open drawer %s", drawer.Name)
+	}
+	return dw, nil
+}
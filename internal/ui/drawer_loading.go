@@ -0,0 +1,158 @@
+package ui
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/deadlyedge/goDrawer/internal/browse"
+)
+
+// maxReportedErrors bounds how many unreadable entries the status tooltip
+// names.
+const maxReportedErrors = 10
+
+// folderLoad is a listing of a folder running in the background.
+type folderLoad struct {
+	cancel context.CancelFunc
+	path   string
+	count  int
+	failed []browse.Item
+	// refresh loads collect the items and merge them into the table at
+	// the end instead of adding them as they come.
+	refresh bool
+	items   []browse.Item
+}
+
+// load lists the current folder in the background, adding entries to the
+// table as they are read. A listing still running is canceled first.
+func (dw *drawerWindow) load() {
+	dw.model.Reset(nil)
+	dw.setStatus("Loading…", "")
+	dw.startLoad(false)
+}
+
+// refresh lists the current folder again and merges the result into the
+// table, keeping the selection and scroll position. A listing still
+// running is taken over: the entries it has not shown yet come with the
+// merge.
+func (dw *drawerWindow) refresh() {
+	dw.startLoad(true)
+}
+
+func (dw *drawerWindow) startLoad(refresh bool) {
+	dw.cancelLoad()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	load := &folderLoad{cancel: cancel, path: dw.currentPath, refresh: refresh}
+	dw.loading = load
+
+	// Batches go through the main window, which outlives the drawer; they
+	// are dropped once the drawer moves on or closes.
+	sync := dw.app.mainWindow.Synchronize
+	fsys, dir := dw.nav.FS(), dw.nav.Current()
+	go func() {
+		err := browse.Stream(ctx, fsys, dir, browse.DefaultBatchSize, func(items []browse.Item) {
+			sync(func() {
+				if dw.loading == load {
+					dw.addBatch(load, items)
+				}
+			})
+		})
+		sync(func() {
+			if dw.loading == load {
+				dw.finishLoad(load, err)
+			}
+		})
+	}()
+}
+
+// cancelLoad stops the listing running, if any.
+func (dw *drawerWindow) cancelLoad() {
+	if dw.loading == nil {
+		return
+	}
+	dw.loading.cancel()
+	dw.loading = nil
+}
+
+func (dw *drawerWindow) addBatch(load *folderLoad, items []browse.Item) {
+	load.count += len(items)
+	for _, item := range items {
+		if item.Err != nil {
+			load.failed = append(load.failed, item)
+		}
+	}
+	if load.refresh {
+		load.items = append(load.items, items...)
+		return
+	}
+	dw.model.Append(items)
+	dw.setStatus(fmt.Sprintf("Loading… %d", load.count), "")
+}
+
+func (dw *drawerWindow) finishLoad(load *folderLoad, err error) {
+	load.cancel()
+	dw.loading = nil
+
+	if load.refresh && err == nil {
+		dw.updateItems(load.items)
+	}
+
+	switch {
+	case err != nil:
+		log.Printf("failed to list %s: %v", load.path, err)
+		dw.setStatus("Unreadable", err.Error())
+	case len(load.failed) > 0:
+		dw.setStatus(fmt.Sprintf("%d unreadable", len(load.failed)), failedSummary(load.failed))
+	default:
+		dw.setStatus("", "")
+	}
+}
+
+// updateItems merges items into the table. The current item stays
+// current even when a change moved it to another row.
+func (dw *drawerWindow) updateItems(items []browse.Item) {
+	current := ""
+	if i := dw.tableView.CurrentIndex(); i >= 0 && i < dw.model.list.Len() {
+		current = dw.model.list.At(i).Name
+	}
+
+	dw.model.Update(items)
+
+	if current == "" {
+		return
+	}
+	if i := dw.tableView.CurrentIndex(); i >= 0 && i < dw.model.list.Len() && dw.model.list.At(i).Name == current {
+		return
+	}
+	for i, item := range dw.model.list.Items() {
+		if item.Name == current {
+			dw.tableView.SetCurrentIndex(i)
+			return
+		}
+	}
+}
+
+// setStatus shows text in the header, with tooltip for details.
+func (dw *drawerWindow) setStatus(text, tooltip string) {
+	if dw.statusLabel == nil {
+		return
+	}
+	dw.statusLabel.SetText(text)
+	dw.statusLabel.SetToolTipText(tooltip)
+}
+
+// failedSummary lists the first unreadable entries and why.
+func failedSummary(items []browse.Item) string {
+	var b strings.Builder
+	for i, item := range items {
+		if i == maxReportedErrors {
+			fmt.Fprintf(&b, "and %d more", len(items)-i)
+			break
+		}
+		fmt.Fprintf(&b, "%s: %v\n", item.Name, item.Err)
+	}
+	return strings.TrimSuffix(b.String(), "\n")
+}
@@ -0,0 +1,242 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+	"github.com/lxn/walk"
+)
+
+// dragThreshold is how far the cursor must travel with the button held
+// before a press on a drawer turns into a reorder drag.
+const dragThreshold = 4
+
+type drawerDrag struct {
+	item   *drawerItemView
+	startX int
+	startY int
+	active bool
+}
+
+// drawerMenu builds the context menu of a drawer row.
+func (a *App) drawerMenu(drawer settings.Drawer) (*walk.Menu, error) {
+	menu, err := walk.NewMenu()
+	if err != nil {
+		return nil, err
+	}
+
+	id := drawer.ID
+	actions := menu.Actions()
+	actions.Add(newAction("Open", func() { a.openDrawerByID(id) }))
+	actions.Add(walk.NewSeparatorAction())
+	actions.Add(newAction("Rename...", func() { a.onRenameDrawer(id) }))
+	actions.Add(newAction("Change folder...", func() { a.onChangeDrawerFolder(id) }))
+	confine := newAction("Keep inside folder", func() { a.onToggleConfined(id) })
+	confine.SetCheckable(true)
+	confine.SetChecked(drawer.Confined)
+	actions.Add(confine)
+	actions.Add(newAction("Move up", func() { a.onMoveDrawer(id, -1) }))
+	actions.Add(newAction("Move down", func() { a.onMoveDrawer(id, 1) }))
+
+	groupAction, err := a.groupMenuAction(drawer)
+	if err != nil {
+		menu.Dispose()
+		return nil, err
+	}
+	actions.Add(groupAction)
+
+	actions.Add(walk.NewSeparatorAction())
+	actions.Add(newAction("Remove", func() { a.onRemoveDrawer(id) }))
+
+	return menu, nil
+}
+
+func (a *App) onRenameDrawer(id string) {
+	i := a.config.DrawerIndex(id)
+	if i < 0 {
+		return
+	}
+
+	name, ok := promptText(a.mainWindow, "Rename drawer", "Drawer name:", a.config.Drawers[i].Name)
+	if !ok {
+		return
+	}
+	if err := a.store.Update("rename drawer", func(s *settings.Settings) error {
+		return s.RenameDrawer(id, name)
+	}); err != nil {
+		showError(a.mainWindow, "Rename drawer", err)
+	}
+}
+
+func (a *App) onChangeDrawerFolder(id string) {
+	i := a.config.DrawerIndex(id)
+	if i < 0 {
+		return
+	}
+
+	dlg := walk.FileDialog{
+		Title:    "Select drawer folder",
+		FilePath: a.config.ResolvePath(a.config.Drawers[i].Path),
+	}
+	ok, err := dlg.ShowBrowseFolder(a.mainWindow)
+	if err != nil {
+		log.Printf("failed to open folder dialog: %v", err)
+		return
+	}
+	if !ok || dlg.FilePath == "" {
+		return
+	}
+
+	if err := a.store.Update("change drawer folder", func(s *settings.Settings) error {
+		return s.SetDrawerPath(id, settings.ContractPath(dlg.FilePath))
+	}); err != nil {
+		showError(a.mainWindow, "Change folder", err)
+	}
+}
+
+func (a *App) onMoveDrawer(id string, delta int) {
+	if err := a.store.Update("move drawer", func(s *settings.Settings) error {
+		return s.MoveDrawer(id, delta)
+	}); err != nil {
+		log.Printf("failed to move drawer: %v", err)
+	}
+}
+
+func (a *App) onToggleConfined(id string) {
+	i := a.config.DrawerIndex(id)
+	if i < 0 {
+		return
+	}
+
+	confined := !a.config.Drawers[i].Confined
+	if err := a.store.Update("confine drawer", func(s *settings.Settings) error {
+		return s.SetDrawerConfined(id, confined)
+	}); err != nil {
+		log.Printf("failed to change drawer confinement: %v", err)
+	}
+}
+
+func (a *App) onRemoveDrawer(id string) {
+	i := a.config.DrawerIndex(id)
+	if i < 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Remove the drawer %q?\n\nThe folder %s itself is not touched.", a.config.Drawers[i].Name, a.config.ResolvePath(a.config.Drawers[i].Path))
+	if walk.MsgBox(a.mainWindow, "Remove drawer", message, walk.MsgBoxYesNo|walk.MsgBoxIconQuestion) != walk.DlgCmdYes {
+		return
+	}
+
+	if err := a.store.Update("remove drawer", func(s *settings.Settings) error {
+		return s.RemoveDrawer(id)
+	}); err != nil {
+		log.Printf("failed to remove drawer: %v", err)
+	}
+}
+
+// addDrawerForFolder adds a drawer for folder, named after it, to the active
+// profile. A name that is taken gets a numeric suffix; a folder that already
+// has a drawer is reported to the user.
+func (a *App) addDrawerForFolder(folder, name string) {
+	folder = settings.ContractPath(folder)
+	err := a.store.Update("add drawer", func(s *settings.Settings) error {
+		profile := s.ActiveProfileID()
+		candidate := name
+		for n := 2; ; n++ {
+			_, err := s.AddDrawer(settings.Drawer{Name: candidate, Path: folder, Profile: profile, Confined: true})
+			if !errors.Is(err, settings.ErrDuplicateName) {
+				return err
+			}
+			candidate = fmt.Sprintf("%s (%d)", name, n)
+		}
+	})
+	if err != nil {
+		showError(a.mainWindow, "Add drawer", err)
+	}
+}
+
+// attachDrawerDrag wires click-to-open and drag-to-reorder onto the
+// widgets of a drawer row.
+func (a *App) attachDrawerDrag(item *drawerItemView, wb *walk.WindowBase) {
+	wb.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+		if button != walk.LeftButton {
+			return
+		}
+		cx, cy, _ := a.cursorInDrawerContainer()
+		a.drag = &drawerDrag{item: item, startX: cx, startY: cy}
+	})
+
+	wb.MouseMove().Attach(func(x, y int, button walk.MouseButton) {
+		if a.drag == nil || button&walk.LeftButton == 0 {
+			return
+		}
+
+		cx, cy, ok := a.cursorInDrawerContainer()
+		if !ok {
+			return
+		}
+		if !a.drag.active && (abs(cx-a.drag.startX) > dragThreshold || abs(cy-a.drag.startY) > dragThreshold) {
+			a.drag.active = true
+		}
+		if a.drag.active {
+			a.setHoveredDrawer(a.drawerItemAt(cx, cy))
+		}
+	})
+
+	wb.MouseUp().Attach(func(x, y int, button walk.MouseButton) {
+		if button != walk.LeftButton || a.drag == nil {
+			return
+		}
+
+		drag := a.drag
+		a.drag = nil
+
+		if !drag.active {
+			a.openDrawerByID(drag.item.drawer.ID)
+			return
+		}
+
+		cx, cy, ok := a.cursorInDrawerContainer()
+		if !ok {
+			return
+		}
+		target := a.drawerItemAt(cx, cy)
+		if target == nil || target == drag.item {
+			return
+		}
+
+		bounds := target.boundsInContainer()
+		after := cy >= bounds.Y+bounds.Height/2
+		id, targetID := drag.item.drawer.ID, target.drawer.ID
+		if err := a.store.Update("reorder drawers", func(s *settings.Settings) error {
+			return s.MoveDrawerNextTo(id, targetID, after)
+		}); err != nil {
+			log.Printf("failed to reorder drawer: %v", err)
+		}
+	})
+}
+
+// cursorInDrawerContainer returns the cursor position in drawerContainer
+// client coordinates and whether it lies inside the container.
+func (a *App) cursorInDrawerContainer() (int, int, bool) {
+	if a.drawerContainer == nil {
+		return 0, 0, false
+	}
+
+	x, y, ok := a.platform.Windows.CursorPos(handle(a.drawerContainer))
+	if !ok {
+		return 0, 0, false
+	}
+
+	bounds := a.drawerContainer.ClientBounds()
+	return x, y, x >= bounds.X && x < bounds.X+bounds.Width && y >= bounds.Y && y < bounds.Y+bounds.Height
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
@@ -0,0 +1,205 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"path/filepath"
+	"strings"
+
+	"github.com/deadlyedge/goDrawer/internal/browse"
+	"github.com/deadlyedge/goDrawer/internal/settings"
+	"github.com/lxn/walk"
+	"github.com/lxn/win"
+)
+
+// maxBreadcrumbs is how many breadcrumbs the header shows; the ones before
+// are folded into an ellipsis.
+const maxBreadcrumbs = 4
+
+// startNavigator sets up navigation at dw.currentPath and shows it.
+func (dw *drawerWindow) startNavigator() error {
+	volume, location, err := browse.Volume(dw.currentPath)
+	if err != nil {
+		return err
+	}
+	nav, err := browse.NewNavigator(volume, location)
+	if err != nil {
+		return err
+	}
+
+	dw.nav, dw.volume = nav, volume
+	if err := dw.confine(); err != nil {
+		return err
+	}
+	nav.OnChange(func(browse.Event) { dw.showLocation() })
+	dw.showLocation()
+	return nil
+}
+
+// showLocation updates the header for the navigator's location, starts
+// listing it and watches it for changes.
+func (dw *drawerWindow) showLocation() {
+	dw.currentPath = dw.osPath(dw.nav.Current())
+	dw.updateHeader()
+	dw.watch()
+	dw.load()
+}
+
+// confine keeps the navigator inside the drawer folder when the drawer
+// asks for it, and lets it leave otherwise.
+func (dw *drawerWindow) confine() error {
+	if !dw.drawer.Confined {
+		return dw.nav.Confine("")
+	}
+	root, ok := dw.volume.Location(dw.root)
+	if !ok {
+		return fmt.Errorf("%s is not on %s", dw.root, dw.volume.Root)
+	}
+	return dw.nav.Confine(root)
+}
+
+// applyConfinement follows a change of the drawer's Confined setting.
+func (dw *drawerWindow) applyConfinement() {
+	if dw.nav == nil {
+		return
+	}
+	if err := dw.confine(); err != nil {
+		log.Printf("failed to confine drawer %s: %v", dw.drawer.Name, err)
+	}
+	dw.updateHeader()
+}
+
+// osPath turns a navigator location into a file system path.
+func (dw *drawerWindow) osPath(location string) string {
+	return dw.volume.Path(location)
+}
+
+// navigateTo shows the folder at path, which must be on the drive of the
+// drawer.
+func (dw *drawerWindow) navigateTo(path string) error {
+	location, ok := dw.volume.Location(path)
+	if !ok {
+		return fmt.Errorf("%s is not on %s", path, dw.volume.Root)
+	}
+	return dw.nav.Go(location)
+}
+
+// promptLocation lets the user type the folder to go to. Paths are
+// expanded like drawer paths; relative ones start at the current folder.
+func (dw *drawerWindow) promptLocation() {
+	text, ok := promptText(dw.window, "Go to folder", "Folder:", dw.currentPath)
+	if !ok || strings.TrimSpace(text) == "" {
+		return
+	}
+
+	if err := dw.navigateTo(settings.ExpandPath(strings.TrimSpace(text), dw.currentPath)); err != nil {
+		showError(dw.window, "Go to folder", err)
+	}
+}
+
+func (dw *drawerWindow) goBack() {
+	if err := dw.nav.Back(); err != nil && !errors.Is(err, browse.ErrNoHistory) {
+		log.Printf("failed to go back: %v", err)
+	}
+}
+
+func (dw *drawerWindow) goForward() {
+	if err := dw.nav.Forward(); err != nil && !errors.Is(err, browse.ErrNoHistory) {
+		log.Printf("failed to go forward: %v", err)
+	}
+}
+
+func (dw *drawerWindow) goUp() {
+	if err := dw.nav.Up(); err != nil && !errors.Is(err, browse.ErrNoParent) {
+		log.Printf("failed to navigate to parent: %v", err)
+	}
+}
+
+func (dw *drawerWindow) jumpTo(crumb int) {
+	if err := dw.nav.JumpTo(crumb); err != nil {
+		log.Printf("failed to open folder: %v", err)
+	}
+}
+
+func (dw *drawerWindow) onKeyDown(key walk.Key) {
+	switch {
+	case key == walk.KeyBack:
+		dw.goBack()
+	case key == walk.KeyL && walk.ControlDown():
+		dw.promptLocation()
+	}
+}
+
+// updateHeader enables the navigation buttons that lead somewhere and
+// rebuilds the breadcrumbs.
+func (dw *drawerWindow) updateHeader() {
+	if dw.backButton != nil {
+		dw.backButton.SetEnabled(dw.nav.CanGoBack())
+	}
+	if dw.fwdButton != nil {
+		dw.fwdButton.SetEnabled(dw.nav.CanGoForward())
+	}
+	if dw.upButton != nil {
+		dw.upButton.SetEnabled(dw.nav.CanGoUp())
+	}
+	if dw.crumbBar == nil {
+		return
+	}
+
+	dw.crumbBar.SetSuspended(true)
+	defer dw.crumbBar.SetSuspended(false)
+
+	for _, crumb := range dw.crumbs {
+		crumb.Dispose()
+	}
+	dw.crumbs = nil
+
+	crumbs := dw.nav.Breadcrumbs()
+	first := max(0, len(crumbs)-maxBreadcrumbs)
+	if first > 0 {
+		dw.addCrumb("…", first-1)
+	}
+	for i := first; i < len(crumbs); i++ {
+		name := crumbs[i].Name
+		if i == 0 {
+			name = dw.topName()
+		}
+		if len(dw.crumbs) > 0 {
+			name = "› " + name
+		}
+		dw.addCrumb(name, i)
+	}
+}
+
+// addCrumb adds a breadcrumb that jumps to the i-th segment when clicked.
+func (dw *drawerWindow) addCrumb(text string, i int) {
+	label, err := walk.NewLabelWithStyle(dw.crumbBar, win.SS_NOTIFY)
+	if err != nil {
+		log.Printf("failed to create breadcrumb: %v", err)
+		return
+	}
+	label.SetText(text)
+	label.SetCursor(walk.CursorHand())
+	label.SetTextColor(dw.app.palette.TextPrimary)
+	label.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+		if button == walk.LeftButton {
+			// The jump disposes this label, so it waits until the click is
+			// handled.
+			dw.window.Synchronize(func() { dw.jumpTo(i) })
+		}
+	})
+	dw.crumbs = append(dw.crumbs, label)
+}
+
+// topName names the first breadcrumb: the drawer for a confined drawer,
+// else the drive.
+func (dw *drawerWindow) topName() string {
+	if dw.nav.Confined() {
+		return dw.drawer.Name
+	}
+	if name := strings.TrimSuffix(dw.volume.Root, string(filepath.Separator)); name != "" {
+		return name
+	}
+	return dw.volume.Root
+}
@@ -0,0 +1,150 @@
+package ui
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/deadlyedge/goDrawer/internal/browse"
+	"github.com/deadlyedge/goDrawer/internal/platform"
+)
+
+const (
+	// refreshDelay is how long a drawer waits for a burst of changes to
+	// settle before listing its folder again; refreshMaxDelay bounds the
+	// wait while changes keep coming.
+	refreshDelay    = 250 * time.Millisecond
+	refreshMaxDelay = 2 * time.Second
+
+	// folderPollInterval is how often folders are listed when the host
+	// cannot report their changes.
+	folderPollInterval = 2 * time.Second
+)
+
+// folderWatch follows the changes to the folder a drawer shows.
+type folderWatch struct {
+	path string
+	// fsys and location place the folder for polling.
+	fsys     fs.FS
+	location string
+	watcher  platform.Watcher
+	pending  *coalescer
+}
+
+// watch starts following changes to the current folder, in place of the
+// folder watched so far. Folders the host cannot watch are polled, and so
+// are folders whose watch fails later on.
+func (dw *drawerWindow) watch() {
+	dw.unwatch()
+
+	w := &folderWatch{path: dw.currentPath, fsys: dw.nav.FS(), location: dw.nav.Current()}
+	w.pending = &coalescer{delay: refreshDelay, maxDelay: refreshMaxDelay, fn: func() {
+		dw.app.mainWindow.Synchronize(func() {
+			if dw.watching == w {
+				dw.refresh()
+			}
+		})
+	}}
+	onFail := func(err error) {
+		dw.app.mainWindow.Synchronize(func() {
+			if dw.watching == w {
+				dw.pollInstead(w, err)
+			}
+		})
+	}
+
+	watcher, err := dw.app.platform.Watchers.Watch(w.path, w.pending.Trigger, onFail)
+	if err != nil {
+		if !errors.Is(err, errors.ErrUnsupported) {
+			log.Printf("warn: polling %s instead: %v", w.path, err)
+		}
+		watcher = w.poll()
+	}
+	w.watcher = watcher
+	dw.watching = w
+}
+
+// pollInstead replaces a watch that ended on its own by polling. The
+// folder is listed again at once, as it may be gone; once it comes back
+// polling picks it up.
+func (dw *drawerWindow) pollInstead(w *folderWatch, err error) {
+	log.Printf("warn: polling %s instead: %v", w.path, err)
+	if err := w.watcher.Close(); err != nil {
+		log.Printf("warn: failed to stop watching %s: %v", w.path, err)
+	}
+	w.watcher = w.poll()
+	w.pending.Trigger()
+}
+
+func (w *folderWatch) poll() *browse.Poller {
+	return browse.Poll(w.fsys, w.location, folderPollInterval, w.pending.Trigger)
+}
+
+// unwatch stops following changes. A change reported after it is ignored
+// by the stopped coalescer, and a refresh already on its way by the check
+// of dw.watching.
+func (dw *drawerWindow) unwatch() {
+	w := dw.watching
+	if w == nil {
+		return
+	}
+	dw.watching = nil
+	if err := w.watcher.Close(); err != nil {
+		log.Printf("warn: failed to stop watching %s: %v", w.path, err)
+	}
+	w.pending.Stop()
+}
+
+// coalescer turns a burst of Trigger calls into one call of fn, made once
+// no Trigger came for delay, or maxDelay after the first.
+type coalescer struct {
+	delay    time.Duration
+	maxDelay time.Duration
+	fn       func()
+
+	mu      sync.Mutex
+	timer   *time.Timer
+	first   time.Time
+	stopped bool
+}
+
+func (c *coalescer) Trigger() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.stopped {
+		return
+	}
+	if c.timer == nil {
+		c.first = time.Now()
+		c.timer = time.AfterFunc(c.delay, c.fire)
+		return
+	}
+	// A timer that already went off has its call on the way, and that
+	// call lists the folder after this change.
+	if left := c.maxDelay - time.Since(c.first); left > 0 && c.timer.Stop() {
+		c.timer.Reset(min(c.delay, left))
+	}
+}
+
+// Stop drops a pending call and every later Trigger, which a watcher may
+// still make after it was closed.
+func (c *coalescer) Stop() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.stopped = true
+	if c.timer != nil {
+		c.timer.Stop()
+		c.timer = nil
+	}
+}
+
+func (c *coalescer) fire() {
+	c.mu.Lock()
+	c.timer = nil
+	c.mu.Unlock()
+	c.fn()
+}
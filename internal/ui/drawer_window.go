@@ -5,59 +5,74 @@ import (
 	"log"
 	"os"
 	"path/filepath"
-	"sort"
-	"syscall"
-	"time"
+	"strings"
 
+	"github.com/deadlyedge/goDrawer/internal/browse"
 	"github.com/deadlyedge/goDrawer/internal/settings"
 	"github.com/lxn/walk"
 	"github.com/lxn/walk/declarative"
-	"github.com/lxn/win"
 )
 
 type drawerWindow struct {
 	app         *App
 	drawer      settings.Drawer
+	root        string // drawer.Path with variables and tokens expanded
 	window      *walk.MainWindow
 	header      *walk.Composite
-	pathLabel   *walk.Label
+	backButton  *walk.PushButton
+	fwdButton   *walk.PushButton
+	upButton    *walk.PushButton
+	crumbBar    *walk.Composite
+	crumbs      []*walk.Label
+	statusLabel *walk.Label
+	filterEdit  *walk.LineEdit
 	tableView   *walk.TableView
 	model       *fileTableModel
+	nav         *browse.Navigator
+	volume      *browse.VolumeFS
 	currentPath string
+	loading     *folderLoad
+	watching    *folderWatch
 }
 
-type fileItem struct {
-	Name    string
-	Path    string
-	IsDir   bool
-	Size    int64
-	ModTime time.Time
-}
+var defaultColumnWidths = []int{220, 90, 140}
 
+// fileTableModel shows a browse.List in a walk.TableView.
 type fileTableModel struct {
 	walk.TableModelBase
-	items []fileItem
+	walk.SorterBase
+	list browse.List
 }
 
 func (m *fileTableModel) RowCount() int {
-	return len(m.items)
+	return m.list.Len()
 }
 
 func (m *fileTableModel) Value(row, col int) interface{} {
-	if row < 0 || row >= len(m.items) {
+	if row < 0 || row >= m.list.Len() {
 		return nil
 	}
 
-	item := m.items[row]
+	item := m.list.At(row)
 	switch col {
 	case 0:
 		return item.Name
 	case 1:
-		if item.IsDir {
+		switch {
+		case item.Err != nil:
+			return "Unreadable"
+		case item.IsDir && item.Link:
+			return "Folder link"
+		case item.IsDir:
 			return "Folder"
+		case item.Link:
+			return "Link"
 		}
 		return fmt.Sprintf("%d KB", item.Size/1024)
 	case 2:
+		if item.Err != nil {
+			return ""
+		}
 		return item.ModTime.Format("2006-01-02 15:04")
 	default:
 		return ""
@@ -65,51 +80,68 @@ func (m *fileTableModel) Value(row, col int) interface{} {
 }
 
 func (m *fileTableModel) Sort(col int, order walk.SortOrder) error {
-	less := func(i, j int) bool {
-		lhs := m.items[i]
-		rhs := m.items[j]
-
-		switch col {
-		case 0:
-			if lhs.IsDir != rhs.IsDir {
-				return lhs.IsDir
-			}
-			if order == walk.SortAscending {
-				return lhs.Name < rhs.Name
-			}
-			return lhs.Name > rhs.Name
-		case 1:
-			if lhs.IsDir != rhs.IsDir {
-				return lhs.IsDir
-			}
-			if order == walk.SortAscending {
-				return lhs.Size < rhs.Size
-			}
-			return lhs.Size > rhs.Size
-		case 2:
-			if order == walk.SortAscending {
-				return lhs.ModTime.Before(rhs.ModTime)
+	m.list.Sort(browse.Column(col), order == walk.SortDescending)
+	m.PublishRowsReset()
+	return m.SorterBase.Sort(col, order)
+}
+
+func (m *fileTableModel) Reset(items []browse.Item) {
+	m.list.Reset(items)
+	m.PublishRowsReset()
+}
+
+func (m *fileTableModel) Append(items []browse.Item) {
+	m.list.Append(items)
+	m.PublishRowsReset()
+}
+
+// Update replaces the items row by row, so that the table view keeps its
+// selection and scroll position.
+func (m *fileTableModel) Update(items []browse.Item) {
+	first, last := -1, -1
+	for _, edit := range m.list.Update(items) {
+		switch edit.Op {
+		case browse.EditRemove:
+			m.PublishRowsRemoved(edit.Index, edit.Index)
+		case browse.EditInsert:
+			m.PublishRowsInserted(edit.Index, edit.Index)
+		case browse.EditChange:
+			if first < 0 {
+				first = edit.Index
 			}
-			return lhs.ModTime.After(rhs.ModTime)
-		default:
-			return true
+			last = edit.Index
 		}
 	}
+	if first >= 0 {
+		m.PublishRowsChanged(first, last)
+	}
+}
 
-	sort.SliceStable(m.items, less)
+// SetFilter shows only items whose name contains filter, ignoring case.
+// Filters containing * or ? are matched as glob patterns instead.
+func (m *fileTableModel) SetFilter(filter string) {
+	m.list.SetFilter(filter)
 	m.PublishRowsReset()
-	return nil
 }
 
-func (m *fileTableModel) Reset(items []fileItem) {
-	m.items = items
-	m.PublishRowsReset()
+func (a *App) openDrawerByID(id string) {
+	if i := a.config.DrawerIndex(id); i >= 0 {
+		a.openDrawer(a.config.Drawers[i])
+	}
 }
 
 func (a *App) openDrawer(drawer settings.Drawer) {
+	if existing := a.drawerWindowByID(drawer.ID); existing != nil {
+		existing.window.Show()
+		existing.window.BringToTop()
+		existing.window.SetFocus()
+		return
+	}
+
 	dw := &drawerWindow{
 		app:    a,
 		drawer: drawer,
+		root:   a.config.ResolvePath(drawer.Path),
 		model:  &fileTableModel{},
 	}
 
@@ -122,11 +154,24 @@ func (a *App) openDrawer(drawer settings.Drawer) {
 }
 
 func (dw *drawerWindow) open() error {
-	dw.currentPath = dw.drawer.Path
+	view := dw.drawer.ViewOrDefault()
+
+	dw.currentPath = dw.root
+	if sub := view.Subpath; sub != "" {
+		candidate := filepath.Join(dw.root, sub)
+		if info, err := os.Stat(candidate); err == nil && info.IsDir() {
+			dw.currentPath = candidate
+		}
+	}
+
+	widths := defaultColumnWidths
+	if len(view.ColumnWidths) == len(defaultColumnWidths) {
+		widths = view.ColumnWidths
+	}
 
 	dragHandler := func(x, y int, button walk.MouseButton) {
 		if button == walk.LeftButton && dw.window != nil {
-			beginWindowDrag(dw.window)
+			dw.app.beginWindowDrag(dw.window)
 		}
 	}
 
@@ -146,23 +191,53 @@ func (dw *drawerWindow) open() error {
 				},
 				Children: []declarative.Widget{
 					declarative.PushButton{
-						Text:      "Up",
-						MinSize:   declarative.Size{Width: 48, Height: 30},
-						OnClicked: func() { dw.goUp() },
+						AssignTo:    &dw.backButton,
+						Text:        "\u2190",
+						ToolTipText: "Back (Backspace)",
+						MaxSize:     declarative.Size{Width: 30, Height: 30},
+						OnClicked:   func() { dw.goBack() },
+					},
+					declarative.PushButton{
+						AssignTo:    &dw.fwdButton,
+						Text:        "\u2192",
+						ToolTipText: "Forward",
+						MaxSize:     declarative.Size{Width: 30, Height: 30},
+						OnClicked:   func() { dw.goForward() },
+					},
+					declarative.PushButton{
+						AssignTo:    &dw.upButton,
+						Text:        "\u2191",
+						ToolTipText: "Up",
+						MaxSize:     declarative.Size{Width: 30, Height: 30},
+						OnClicked:   func() { dw.goUp() },
+					},
+					declarative.Composite{
+						AssignTo:    &dw.crumbBar,
+						Layout:      declarative.HBox{MarginsZero: true, Spacing: 2},
+						OnMouseDown: dragHandler,
 					},
 					declarative.HSpacer{},
 					declarative.Label{
-						AssignTo:    &dw.pathLabel,
-						Text:        dw.currentPath,
+						AssignTo:    &dw.statusLabel,
 						OnMouseDown: dragHandler,
 					},
+					declarative.LineEdit{
+						AssignTo:  &dw.filterEdit,
+						Text:      view.Filter,
+						CueBanner: "Filter",
+						MaxSize:   declarative.Size{Width: 120},
+						OnTextChanged: func() {
+							dw.model.SetFilter(dw.filterEdit.Text())
+						},
+					},
 				},
 			},
 			declarative.TableView{
 				AssignTo:            &dw.tableView,
-				Columns:             []declarative.TableViewColumn{{Title: "Name", Width: 220}, {Title: "Info", Width: 90}, {Title: "Modified", Width: 140}},
+				Columns:             []declarative.TableViewColumn{{Title: "Name", Width: widths[0]}, {Title: "Info", Width: widths[1]}, {Title: "Modified", Width: widths[2]}},
 				LastColumnStretched: true,
 				OnItemActivated:     func() { dw.openSelected() },
+				OnKeyDown:           dw.onKeyDown,
 			},
 		},
 	}
@@ -171,29 +246,44 @@ func (dw *drawerWindow) open() error {
 		return err
 	}
 
-	dw.tableView.SetModel(dw.model)
-
-	if err := makeWindowBorderless(dw.window); err != nil {
+	headerMenu, err := walk.NewMenu()
+	if err != nil {
 		return err
 	}
-	if err := makeWindowSemiTransparent(dw.window, dw.app.palette.Alpha); err != nil {
-		return err
+	headerMenu.Actions().Add(newAction("Go to folder...\tCtrl+L", dw.promptLocation))
+	dw.header.SetContextMenu(headerMenu)
+	dw.crumbBar.SetContextMenu(headerMenu)
+	dw.window.Disposing().Attach(func() { headerMenu.Dispose() })
+
+	dw.model.list.SetFilter(view.Filter)
+	dw.tableView.SetModel(dw.model)
+	sortColumn := view.SortColumn
+	if sortColumn < 0 || sortColumn >= len(defaultColumnWidths) {
+		sortColumn = 0
 	}
-	if err := hideFromTaskbar(dw.window); err != nil {
-		return err
+	dw.model.Sort(sortColumn, sortOrderFromSettings(view.SortOrder))
+
+	if pos := view.Position; pos != nil {
+		dw.window.SetBounds(walk.Rectangle{X: pos.X, Y: pos.Y, Width: dw.drawer.Size.Width, Height: dw.drawer.Size.Height})
 	}
 
-	dw.applyTheme()
+	if err := dw.app.styleToolWindow(dw.window); err != nil {
+		return err
+	}
 
-	if err := dw.loadDirectory(dw.currentPath); err != nil {
+	if err := dw.startNavigator(); err != nil {
 		return err
 	}
+	dw.applyTheme()
 
 	dw.window.Closing().Attach(func(canceled *bool, reason walk.CloseReason) {
 		dw.saveSize()
+		dw.saveViewState()
 		dw.app.persistDrawerSettings(dw.drawer)
 	})
 	dw.window.Disposing().Attach(func() {
+		dw.unwatch()
+		dw.cancelLoad()
 		dw.app.unregisterDrawer(dw)
 	})
 
@@ -208,58 +298,17 @@ func (dw *drawerWindow) applyTheme() {
 	if dw.header != nil && dw.app.brushes.AccentDark != nil {
 		dw.header.SetBackground(dw.app.brushes.AccentDark)
 	}
-	if dw.pathLabel != nil {
-		dw.pathLabel.SetTextColor(dw.app.palette.TextPrimary)
-	}
-	if dw.tableView != nil && dw.app.brushes.Surface != nil {
-		dw.tableView.SetBackground(dw.app.brushes.Surface)
-	}
-}
-
-func (dw *drawerWindow) loadDirectory(path string) error {
-	entries, err := os.ReadDir(path)
-	if err != nil {
-		return err
+	if dw.crumbBar != nil && dw.app.brushes.AccentDark != nil {
+		dw.crumbBar.SetBackground(dw.app.brushes.AccentDark)
 	}
-
-	var items []fileItem
-	for _, entry := range entries {
-		info, err := entry.Info()
-		if err != nil {
-			continue
-		}
-		items = append(items, fileItem{
-			Name:    entry.Name(),
-			Path:    filepath.Join(path, entry.Name()),
-			IsDir:   entry.IsDir(),
-			Size:    info.Size(),
-			ModTime: info.ModTime(),
-		})
-	}
-
-	sort.SliceStable(items, func(i, j int) bool {
-		if items[i].IsDir != items[j].IsDir {
-			return items[i].IsDir
-		}
-		return items[i].Name < items[j].Name
-	})
-
-	dw.model.Reset(items)
-	dw.currentPath = path
-	if dw.pathLabel != nil {
-		dw.pathLabel.SetText(path)
+	for _, crumb := range dw.crumbs {
+		crumb.SetTextColor(dw.app.palette.TextPrimary)
 	}
-
-	return nil
-}
-
-func (dw *drawerWindow) goUp() {
-	parent := filepath.Dir(dw.currentPath)
-	if parent == dw.currentPath || parent == "" {
-		return
+	if dw.statusLabel != nil {
+		dw.statusLabel.SetTextColor(dw.app.palette.TextSecondary)
 	}
-	if err := dw.loadDirectory(parent); err != nil {
-		log.Printf("failed to navigate to parent: %v", err)
+	if dw.tableView != nil && dw.app.brushes.Surface != nil {
+		dw.tableView.SetBackground(dw.app.brushes.Surface)
 	}
 }
 
@@ -268,19 +317,24 @@ func (dw *drawerWindow) openSelected() {
 		return
 	}
 	index := dw.tableView.CurrentIndex()
-	if index < 0 || index >= len(dw.model.items) {
+	if index < 0 || index >= dw.model.list.Len() {
+		return
+	}
+	item := dw.model.list.At(index)
+	if !dw.nav.Reachable(item.Path) {
+		showError(dw.window, "Open", fmt.Errorf("%s leads outside the drawer folder and is not followed", item.Name))
 		return
 	}
-	item := dw.model.items[index]
 	if item.IsDir {
-		if err := dw.loadDirectory(item.Path); err != nil {
-			log.Printf("failed to open folder %s: %v", item.Path, err)
+		if err := dw.nav.Go(item.Path); err != nil {
+			log.Printf("failed to open folder %s: %v", dw.osPath(item.Path), err)
 		}
 		return
 	}
 
-	if err := shellOpen(item.Path); err != nil {
-		log.Printf("failed to open file %s: %v", item.Path, err)
+	path := dw.osPath(item.Path)
+	if err := dw.app.platform.Shell.Open(path); err != nil {
+		log.Printf("failed to open file %s: %v", path, err)
 	}
 }
 
@@ -306,22 +360,64 @@ func (dw *drawerWindow) saveSize() {
 	dw.drawer.Size = settings.Size{Width: size.Width, Height: size.Height}
 }
 
-func (a *App) unregisterDrawer(dw *drawerWindow) {
-	for i, existing := range a.drawerWindows {
-		if existing == dw {
-			a.drawerWindows = append(a.drawerWindows[:i], a.drawerWindows[i+1:]...)
-			break
+// saveViewState captures sorting, column widths, location, window position
+// and filter into dw.drawer so they are restored on the next open.
+func (dw *drawerWindow) saveViewState() {
+	if dw.window == nil {
+		return
+	}
+
+	view := &settings.ViewState{}
+	dw.drawer.View = view
+	view.SortColumn = dw.model.SortedColumn()
+	view.SortOrder = sortOrderToSettings(dw.model.SortOrder())
+	view.Filter = dw.model.list.Filter()
+
+	if dw.tableView != nil {
+		columns := dw.tableView.Columns()
+		view.ColumnWidths = make([]int, columns.Len())
+		for i := range view.ColumnWidths {
+			view.ColumnWidths[i] = columns.At(i).Width()
 		}
 	}
+
+	view.Subpath = ""
+	if rel, err := filepath.Rel(dw.root, dw.currentPath); err == nil && rel != "." && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		view.Subpath = rel
+	}
+
+	bounds := dw.window.Bounds()
+	view.Position = &settings.Point{X: bounds.X, Y: bounds.Y}
 }
 
-func shellOpen(path string) error {
-	filePtr, err := syscall.UTF16PtrFromString(path)
-	if err != nil {
-		return err
+func sortOrderFromSettings(order string) walk.SortOrder {
+	if order == settings.SortDescending {
+		return walk.SortDescending
+	}
+	return walk.SortAscending
+}
+
+func sortOrderToSettings(order walk.SortOrder) string {
+	if order == walk.SortDescending {
+		return settings.SortDescending
 	}
-	if !win.ShellExecute(0, nil, filePtr, nil, nil, win.SW_SHOWNORMAL) {
-		return fmt.Errorf("shell execute failed for %s", path)
+	return settings.SortAscending
+}
+
+func (a *App) drawerWindowByID(id string) *drawerWindow {
+	for _, dw := range a.drawerWindows {
+		if dw.drawer.ID == id && dw.window != nil {
+			return dw
+		}
 	}
 	return nil
 }
+
+func (a *App) unregisterDrawer(dw *drawerWindow) {
+	for i, existing := range a.drawerWindows {
+		if existing == dw {
+			a.drawerWindows = append(a.drawerWindows[:i], a.drawerWindows[i+1:]...)
+			break
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package ui
+
+import (
+	"errors"
+	"log"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+	"github.com/lxn/walk"
+	"github.com/lxn/walk/declarative"
+)
+
+func (a *App) undo() {
+	if err := a.store.Undo(); err != nil && !errors.Is(err, settings.ErrNothingToUndo) {
+		log.Printf("failed to undo: %v", err)
+	}
+	a.updateTray()
+}
+
+func (a *App) redo() {
+	if err := a.store.Redo(); err != nil && !errors.Is(err, settings.ErrNothingToRedo) {
+		log.Printf("failed to redo: %v", err)
+	}
+	a.updateTray()
+}
+
+// showHistory lists the previous settings states and restores the one the
+// user picks.
+func (a *App) showHistory() {
+	snapshots := a.store.History()
+	if len(snapshots) == 0 {
+		walk.MsgBox(a.mainWindow, "Settings history", "There are no earlier settings to restore yet.", walk.MsgBoxOK|walk.MsgBoxIconInformation)
+		return
+	}
+
+	items := make([]string, len(snapshots))
+	for i, snapshot := range snapshots {
+		items[i] = snapshot.String()
+	}
+
+	var (
+		dlg       *walk.Dialog
+		list      *walk.ListBox
+		restorePB *walk.PushButton
+		closePB   *walk.PushButton
+		picked    = -1
+	)
+
+	restore := func() {
+		if i := list.CurrentIndex(); i >= 0 {
+			picked = i
+			dlg.Accept()
+		}
+	}
+
+	def := declarative.Dialog{
+		AssignTo:      &dlg,
+		Title:         "Settings history",
+		DefaultButton: &restorePB,
+		CancelButton:  &closePB,
+		MinSize:       declarative.Size{Width: 420, Height: 320},
+		Layout:        declarative.VBox{},
+		Children: []declarative.Widget{
+			declarative.Label{Text: "Restore the settings as they were before:"},
+			declarative.ListBox{
+				AssignTo:        &list,
+				Model:           items,
+				CurrentIndex:    0,
+				OnItemActivated: restore,
+			},
+			declarative.Composite{
+				Layout: declarative.HBox{MarginsZero: true},
+				Children: []declarative.Widget{
+					declarative.HSpacer{},
+					declarative.PushButton{
+						AssignTo:  &restorePB,
+						Text:      "Restore",
+						OnClicked: restore,
+					},
+					declarative.PushButton{
+						AssignTo:  &closePB,
+						Text:      "Close",
+						OnClicked: func() { dlg.Cancel() },
+					},
+				},
+			},
+		},
+	}
+
+	result, err := def.Run(a.mainWindow)
+	if err != nil {
+		log.Printf("failed to open settings history: %v", err)
+		return
+	}
+	if result != walk.DlgCmdOK || picked < 0 {
+		return
+	}
+
+	if err := a.store.Restore(snapshots[picked].ID); err != nil {
+		showError(a.mainWindow, "Settings history", err)
+	}
+	a.updateTray()
+}
@@ -1,9 +1,11 @@
 package ui
 
 import (
+	"fmt"
 	"log"
 	"path/filepath"
 
+	"github.com/deadlyedge/goDrawer/internal/assets"
 	"github.com/deadlyedge/goDrawer/internal/settings"
 	"github.com/lxn/walk"
 	"github.com/lxn/walk/declarative"
@@ -49,6 +51,31 @@ func (item *drawerItemView) applyPalette(hover bool) {
 	}
 }
 
+// groupHeaderView is the clickable section title of a drawer group.
+type groupHeaderView struct {
+	app   *App
+	group settings.Group
+	root  *walk.Composite
+	label *walk.Label
+}
+
+func (header *groupHeaderView) applyPalette() {
+	if header == nil || header.app == nil {
+		return
+	}
+
+	if header.root != nil && header.app.brushes.AccentDark != nil {
+		header.root.SetBackground(header.app.brushes.AccentDark)
+	}
+	if header.label != nil {
+		header.label.SetTextColor(header.app.palette.TextPrimary)
+		if header.app.brushes.AccentDark != nil {
+			header.label.SetBackground(header.app.brushes.AccentDark)
+		}
+		header.label.Invalidate()
+	}
+}
+
 func (item *drawerItemView) boundsInContainer() walk.Rectangle {
 	if item == nil || item.root == nil {
 		return walk.Rectangle{}
@@ -59,14 +86,14 @@ func (item *drawerItemView) boundsInContainer() walk.Rectangle {
 func (a *App) createMainWindow() error {
 	dragHandler := func(x, y int, button walk.MouseButton) {
 		if button == walk.LeftButton && a.mainWindow != nil {
-			beginWindowDrag(a.mainWindow)
+			a.beginWindowDrag(a.mainWindow)
 		}
 	}
 
 	mwDef := declarative.MainWindow{
 		AssignTo:    &a.mainWindow,
 		Title:       "goDrawer",
-		Icon:        filepath.Join("assets", "drawer.icon.4.ico"),
+		Icon:        assets.Path(assets.AppIcon),
 		MinSize:     declarative.Size{Width: 256, Height: 256},
 		Size:        declarative.Size{Width: 256, Height: 256},
 		Layout:      declarative.VBox{MarginsZero: true, Spacing: 0},
@@ -136,13 +163,7 @@ func (a *App) createMainWindow() error {
 		return err
 	}
 
-	if err := makeWindowBorderless(a.mainWindow); err != nil {
-		return err
-	}
-	if err := makeWindowSemiTransparent(a.mainWindow, a.palette.Alpha); err != nil {
-		return err
-	}
-	if err := hideFromTaskbar(a.mainWindow); err != nil {
+	if err := a.styleToolWindow(a.mainWindow); err != nil {
 		return err
 	}
 
@@ -153,6 +174,12 @@ func (a *App) createMainWindow() error {
 		a.drawerContainer.MouseMove().Attach(func(x, y int, button walk.MouseButton) {
 			a.setHoveredDrawer(a.drawerItemAt(x, y))
 		})
+
+		if menu, err := walk.NewMenu(); err == nil {
+			menu.Actions().Add(newAction("New group...", a.onAddGroup))
+			a.drawerContainer.SetContextMenu(menu)
+			a.drawerContainer.Disposing().Attach(func() { menu.Dispose() })
+		}
 	}
 
 	a.mainWindow.MouseMove().Attach(func(int, int, walk.MouseButton) {
@@ -176,19 +203,233 @@ func (a *App) refreshDrawerList() error {
 	}
 
 	a.drawerItems = nil
+	a.groupHeaders = nil
 	a.setHoveredDrawer(nil)
 
-	for _, drawer := range a.config.Drawers {
+	if err := a.addDrawerItems(a.config.DrawersInGroup("")); err != nil {
+		return err
+	}
+
+	for _, group := range a.config.Groups {
+		drawers := a.config.DrawersInGroup(group.ID)
+
+		header, err := a.createGroupHeader(group, len(drawers))
+		if err != nil {
+			return err
+		}
+		a.groupHeaders = append(a.groupHeaders, header)
+
+		if group.Collapsed {
+			continue
+		}
+		if err := a.addDrawerItems(drawers); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// scheduleRefresh rebuilds the drawer list once the current event handler
+// has returned, since the handler's own widget is disposed by the rebuild.
+func (a *App) scheduleRefresh() {
+	if a.mainWindow == nil {
+		return
+	}
+	a.mainWindow.Synchronize(func() {
+		if err := a.refreshDrawerList(); err != nil {
+			log.Printf("failed to refresh drawer list: %v", err)
+		}
+	})
+}
+
+func (a *App) addDrawerItems(drawers []settings.Drawer) error {
+	for _, drawer := range drawers {
 		item, err := a.createDrawerItem(drawer)
 		if err != nil {
 			return err
 		}
 		a.drawerItems = append(a.drawerItems, item)
 	}
-
 	return nil
 }
 
+func (a *App) createGroupHeader(group settings.Group, count int) (*groupHeaderView, error) {
+	comp, err := walk.NewComposite(a.drawerContainer)
+	if err != nil {
+		return nil, err
+	}
+
+	layout := walk.NewHBoxLayout()
+	layout.SetMargins(walk.Margins{HNear: 4, VNear: 0, HFar: 4, VFar: 0})
+	layout.SetSpacing(0)
+	comp.SetLayout(layout)
+
+	header := &groupHeaderView{
+		app:   a,
+		group: group,
+		root:  comp,
+	}
+
+	label, err := walk.NewLabelWithStyle(comp, win.SS_NOTIFY)
+	if err != nil {
+		comp.Dispose()
+		return nil, err
+	}
+	arrow := "\u25BE"
+	if group.Collapsed {
+		arrow = "\u25B8"
+	}
+	label.SetText(fmt.Sprintf("%s %s (%d)", arrow, group.Name, count))
+	label.SetTextAlignment(walk.AlignNear)
+	label.SetMinMaxSize(walk.Size{Width: 0, Height: 24}, walk.Size{})
+	label.SetCursor(walk.CursorHand())
+	layout.SetStretchFactor(label, 1)
+	header.label = label
+
+	menu, err := walk.NewMenu()
+	if err != nil {
+		comp.Dispose()
+		return nil, err
+	}
+	id := group.ID
+	menu.Actions().Add(newAction("Rename group...", func() { a.onRenameGroup(id) }))
+	menu.Actions().Add(newAction("Remove group", func() { a.onRemoveGroup(id) }))
+	comp.Disposing().Attach(func() { menu.Dispose() })
+
+	for _, w := range []walk.Widget{comp, label} {
+		wb := w.AsWindowBase()
+		wb.SetContextMenu(menu)
+		wb.MouseMove().Attach(func(int, int, walk.MouseButton) {
+			a.setHoveredDrawer(nil)
+		})
+		wb.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
+			if button == walk.LeftButton {
+				a.toggleGroup(id)
+			}
+		})
+	}
+
+	header.applyPalette()
+
+	return header, nil
+}
+
+func (a *App) toggleGroup(id string) {
+	if err := a.store.Adjust("toggle group", func(s *settings.Settings) error {
+		i := s.GroupIndex(id)
+		if i < 0 {
+			return settings.ErrNotFound
+		}
+		return s.SetGroupCollapsed(id, !s.Groups[i].Collapsed)
+	}); err != nil {
+		log.Printf("failed to toggle group: %v", err)
+	}
+}
+
+func (a *App) onAddGroup() {
+	name, ok := promptText(a.mainWindow, "New group", "Group name:", "")
+	if !ok {
+		return
+	}
+	if err := a.store.Update("add group", func(s *settings.Settings) error {
+		_, err := s.AddGroup(name)
+		return err
+	}); err != nil {
+		showError(a.mainWindow, "New group", err)
+	}
+}
+
+func (a *App) onRenameGroup(id string) {
+	i := a.config.GroupIndex(id)
+	if i < 0 {
+		return
+	}
+	name, ok := promptText(a.mainWindow, "Rename group", "Group name:", a.config.Groups[i].Name)
+	if !ok {
+		return
+	}
+	if err := a.store.Update("rename group", func(s *settings.Settings) error {
+		return s.RenameGroup(id, name)
+	}); err != nil {
+		showError(a.mainWindow, "Rename group", err)
+	}
+}
+
+func (a *App) onRemoveGroup(id string) {
+	if err := a.store.Update("remove group", func(s *settings.Settings) error {
+		return s.RemoveGroup(id)
+	}); err != nil {
+		log.Printf("failed to remove group: %v", err)
+	}
+}
+
+func (a *App) onMoveDrawerToGroup(drawerID, groupID string) {
+	if err := a.store.Update("move drawer to group", func(s *settings.Settings) error {
+		return s.MoveDrawerToGroup(drawerID, groupID)
+	}); err != nil {
+		log.Printf("failed to move drawer: %v", err)
+	}
+}
+
+// groupMenuAction builds the "Move to group" submenu for a drawer.
+func (a *App) groupMenuAction(drawer settings.Drawer) (*walk.Action, error) {
+	submenu, err := walk.NewMenu()
+	if err != nil {
+		return nil, err
+	}
+
+	id := drawer.ID
+	current := a.config.DrawersInGroup("")
+	ungrouped := newAction("(No group)", func() { a.onMoveDrawerToGroup(id, "") })
+	ungrouped.SetChecked(containsDrawer(current, id))
+	submenu.Actions().Add(ungrouped)
+
+	for _, group := range a.config.Groups {
+		groupID := group.ID
+		action := newAction(group.Name, func() { a.onMoveDrawerToGroup(id, groupID) })
+		action.SetChecked(drawer.Group == groupID)
+		submenu.Actions().Add(action)
+	}
+
+	submenu.Actions().Add(walk.NewSeparatorAction())
+	submenu.Actions().Add(newAction("New group...", func() {
+		name, ok := promptText(a.mainWindow, "New group", "Group name:", "")
+		if !ok {
+			return
+		}
+		if err := a.store.Update("add group", func(s *settings.Settings) error {
+			group, err := s.AddGroup(name)
+			if err != nil {
+				return err
+			}
+			return s.MoveDrawerToGroup(id, group.ID)
+		}); err != nil {
+			showError(a.mainWindow, "New group", err)
+		}
+	}))
+
+	action := walk.NewMenuAction(submenu)
+	action.SetText("Move to group")
+	return action, nil
+}
+
+func containsDrawer(drawers []settings.Drawer, id string) bool {
+	for _, drawer := range drawers {
+		if drawer.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
+func newAction(text string, handler func()) *walk.Action {
+	action := walk.NewAction()
+	action.SetText(text)
+	action.Triggered().Attach(handler)
+	return action
+}
+
 func (a *App) onAddDrawer() {
 	if a.mainWindow == nil {
 		return
@@ -212,21 +453,7 @@ func (a *App) onAddDrawer() {
 		name = folder
 	}
 
-	newDrawer := settings.Drawer{
-		Name: name,
-		Path: folder,
-		Size: settings.Size{Width: 420, Height: 360},
-	}
-
-	a.config.Drawers = append(a.config.Drawers, newDrawer)
-
-	if err := settings.Update(a.settingsPath, a.config); err != nil {
-		log.Printf("failed to persist settings: %v", err)
-	}
-
-	if err := a.refreshDrawerList(); err != nil {
-		log.Printf("failed to refresh drawer list: %v", err)
-	}
+	a.addDrawerForFolder(folder, name)
 }
 
 func (a *App) createDrawerItem(drawer settings.Drawer) (*drawerItemView, error) {
@@ -265,18 +492,22 @@ func (a *App) createDrawerItem(drawer settings.Drawer) (*drawerItemView, error)
 
 	comp.SetCursor(walk.CursorHand())
 
-	current := drawer
+	menu, err := a.drawerMenu(drawer)
+	if err != nil {
+		comp.Dispose()
+		return nil, err
+	}
+	comp.Disposing().Attach(func() { menu.Dispose() })
 
 	for _, w := range []walk.Widget{comp, label} {
 		wb := w.AsWindowBase()
+		wb.SetContextMenu(menu)
 		wb.MouseMove().Attach(func(int, int, walk.MouseButton) {
-			a.setHoveredDrawer(item)
-		})
-		wb.MouseDown().Attach(func(x, y int, button walk.MouseButton) {
-			if button == walk.LeftButton {
-				a.openDrawer(current)
+			if a.drag == nil || !a.drag.active {
+				a.setHoveredDrawer(item)
 			}
 		})
+		a.attachDrawerDrag(item, wb)
 	}
 
 	item.applyPalette(false)
@@ -0,0 +1,59 @@
+package ui
+
+import (
+	"log"
+	"path/filepath"
+
+	"github.com/deadlyedge/goDrawer/internal/platform"
+	"github.com/lxn/walk"
+)
+
+// handle returns the platform handle of a walk window.
+func handle(w walk.Window) platform.Handle {
+	return platform.Handle(w.Handle())
+}
+
+// styleToolWindow makes w a borderless, translucent window without a
+// taskbar button, the way the main and drawer windows look.
+func (a *App) styleToolWindow(w walk.Window) error {
+	windows := a.platform.Windows
+	if err := windows.MakeBorderless(handle(w)); err != nil {
+		return err
+	}
+	if err := windows.SetOpacity(handle(w), a.palette.Alpha); err != nil {
+		return err
+	}
+	return windows.HideFromTaskbar(handle(w))
+}
+
+// beginWindowDrag moves w with the mouse while the left button is down.
+func (a *App) beginWindowDrag(w walk.Window) {
+	a.platform.Windows.BeginDrag(handle(w))
+}
+
+// syncAutostart registers goDrawer to start at login, with the settings
+// file it runs with, or unregisters it to match the settings.
+func (a *App) syncAutostart() {
+	autostart := a.platform.Autostart
+	if a.config.Startup.StartWithWindows {
+		path, err := filepath.Abs(a.store.Path())
+		if err != nil {
+			path = a.store.Path()
+		}
+		if err := autostart.Enable([]string{"--config", path}); err != nil {
+			log.Printf("warn: failed to enable start with Windows: %v", err)
+		}
+		return
+	}
+
+	enabled, err := autostart.Enabled()
+	if err != nil {
+		log.Printf("warn: failed to check start with Windows: %v", err)
+		return
+	}
+	if enabled {
+		if err := autostart.Disable(); err != nil {
+			log.Printf("warn: failed to disable start with Windows: %v", err)
+		}
+	}
+}
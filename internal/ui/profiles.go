@@ -0,0 +1,121 @@
+package ui
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/deadlyedge/goDrawer/internal/platform"
+	"github.com/deadlyedge/goDrawer/internal/settings"
+	"github.com/lxn/walk"
+)
+
+// profileChoice is an entry of the profile pickers. The shared base has the
+// empty ID.
+type profileChoice struct {
+	ID   string
+	Name string
+}
+
+func (a *App) profileChoices() []profileChoice {
+	choices := []profileChoice{{ID: "", Name: settings.BaseProfileName}}
+	for _, profile := range a.config.Profiles {
+		choices = append(choices, profileChoice{ID: profile.ID, Name: profile.Name})
+	}
+	return choices
+}
+
+// switchProfile activates another profile. The store subscriber rebuilds
+// the drawer list, theme and menus; drawer windows of the old profile are
+// closed.
+func (a *App) switchProfile(id string) {
+	if err := a.store.Update("switch profile", func(s *settings.Settings) error {
+		return s.SetActiveProfile(id)
+	}); err != nil {
+		log.Printf("failed to switch profile: %v", err)
+	}
+}
+
+func (a *App) onAddProfile(owner walk.Form) (string, bool) {
+	name, ok := promptText(owner, "New profile", "Profile name:", "")
+	if !ok {
+		return "", false
+	}
+
+	var profile settings.Profile
+	if err := a.store.Update("add profile", func(s *settings.Settings) error {
+		var err error
+		if profile, err = s.AddProfile(name); err != nil {
+			return err
+		}
+		return s.SetActiveProfile(profile.ID)
+	}); err != nil {
+		showError(owner, "New profile", err)
+		return "", false
+	}
+	return profile.ID, true
+}
+
+func (a *App) onRenameProfile(owner walk.Form, id string) bool {
+	i := a.config.ProfileIndex(id)
+	if i < 0 {
+		return false
+	}
+
+	name, ok := promptText(owner, "Rename profile", "Profile name:", a.config.Profiles[i].Name)
+	if !ok {
+		return false
+	}
+
+	if err := a.store.Update("rename profile", func(s *settings.Settings) error {
+		return s.RenameProfile(id, name)
+	}); err != nil {
+		showError(owner, "Rename profile", err)
+		return false
+	}
+	return true
+}
+
+func (a *App) onRemoveProfile(owner walk.Form, id string) bool {
+	i := a.config.ProfileIndex(id)
+	if i < 0 {
+		return false
+	}
+
+	message := fmt.Sprintf("Remove the profile %q?", a.config.Profiles[i].Name)
+	if count := a.config.ProfileDrawerCount(id); count > 0 {
+		message += fmt.Sprintf("\n\nIts %d drawers are removed as well. The folders themselves are not touched.", count)
+	}
+	if walk.MsgBox(owner, "Remove profile", message, walk.MsgBoxYesNo|walk.MsgBoxIconQuestion) != walk.DlgCmdYes {
+		return false
+	}
+
+	if err := a.store.Update("remove profile", func(s *settings.Settings) error {
+		return s.RemoveProfile(id)
+	}); err != nil {
+		log.Printf("failed to remove profile: %v", err)
+		return false
+	}
+	return true
+}
+
+// profileMenu lists the profiles for the tray "Profile" submenu, with the
+// active one checked.
+func (a *App) profileMenu() []platform.MenuItem {
+	active := a.config.ActiveProfileID()
+
+	var items []platform.MenuItem
+	for _, choice := range a.profileChoices() {
+		id := choice.ID
+		items = append(items, platform.MenuItem{
+			Text:      choice.Name,
+			Checkable: true,
+			Checked:   id == active,
+			OnClick:   func() { a.switchProfile(id) },
+		})
+	}
+
+	return append(items,
+		platform.Separator,
+		platform.MenuItem{Text: "New profile...", OnClick: func() { a.onAddProfile(a.mainWindow) }},
+	)
+}
@@ -0,0 +1,62 @@
+package ui
+
+import (
+	"github.com/lxn/walk"
+	"github.com/lxn/walk/declarative"
+)
+
+// promptText asks for a single line of text. It returns false when the user
+// cancels the dialog.
+func promptText(owner walk.Form, title, label, initial string) (string, bool) {
+	var (
+		dlg      *walk.Dialog
+		edit     *walk.LineEdit
+		acceptPB *walk.PushButton
+		cancelPB *walk.PushButton
+		text     string
+	)
+
+	def := declarative.Dialog{
+		AssignTo:      &dlg,
+		Title:         title,
+		DefaultButton: &acceptPB,
+		CancelButton:  &cancelPB,
+		MinSize:       declarative.Size{Width: 280, Height: 120},
+		Layout:        declarative.VBox{},
+		Children: []declarative.Widget{
+			declarative.Label{Text: label},
+			declarative.LineEdit{AssignTo: &edit, Text: initial},
+			declarative.Composite{
+				Layout: declarative.HBox{MarginsZero: true},
+				Children: []declarative.Widget{
+					declarative.HSpacer{},
+					declarative.PushButton{
+						AssignTo: &acceptPB,
+						Text:     "OK",
+						OnClicked: func() {
+							text = edit.Text()
+							dlg.Accept()
+						},
+					},
+					declarative.PushButton{
+						AssignTo:  &cancelPB,
+						Text:      "Cancel",
+						OnClicked: func() { dlg.Cancel() },
+					},
+				},
+			},
+		},
+	}
+
+	result, err := def.Run(owner)
+	if err != nil || result != walk.DlgCmdOK {
+		return "", false
+	}
+
+	return text, true
+}
+
+// showError reports a failed user action in a message box.
+func showError(owner walk.Form, title string, err error) {
+	walk.MsgBox(owner, title, err.Error(), walk.MsgBoxOK|walk.MsgBoxIconError)
+}
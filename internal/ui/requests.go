@@ -0,0 +1,69 @@
+package ui
+
+import (
+	"log"
+	"strings"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+)
+
+// Request is something asked of the running goDrawer on the command line,
+// either at launch or forwarded by a later launch.
+type Request struct {
+	// OpenDrawer is the ID or name of a drawer to open.
+	OpenDrawer string
+	Show       bool
+	Reload     bool
+}
+
+// Options configures MainWindow.
+type Options struct {
+	// Startup is carried out once the main window is up.
+	Startup Request
+	// Requests delivers the requests of later launches. MainWindow returns
+	// without closing it.
+	Requests <-chan Request
+}
+
+// serveRequests carries out forwarded requests on the UI thread.
+func (a *App) serveRequests(requests <-chan Request) {
+	if requests == nil {
+		return
+	}
+	go func() {
+		for request := range requests {
+			a.mainWindow.Synchronize(func() { a.handleRequest(request) })
+		}
+	}()
+}
+
+func (a *App) handleRequest(request Request) {
+	if request.Reload {
+		a.onSettingsFileChanged()
+	}
+	if request.Show {
+		a.showMainWindow()
+	}
+	if request.OpenDrawer != "" {
+		drawer, ok := findVisibleDrawer(a.config, request.OpenDrawer)
+		if !ok {
+			log.Printf("no drawer %q to open", request.OpenDrawer)
+			return
+		}
+		a.openDrawer(drawer)
+	}
+}
+
+// findVisibleDrawer looks a drawer of the active profile up by ID or,
+// case-insensitively, by name.
+func findVisibleDrawer(s *settings.Settings, ref string) (settings.Drawer, bool) {
+	if i := s.DrawerIndex(ref); i >= 0 && s.DrawerVisible(s.Drawers[i]) {
+		return s.Drawers[i], true
+	}
+	for _, drawer := range s.Drawers {
+		if s.DrawerVisible(drawer) && strings.EqualFold(strings.TrimSpace(drawer.Name), strings.TrimSpace(ref)) {
+			return drawer, true
+		}
+	}
+	return settings.Drawer{}, false
+}
@@ -0,0 +1,170 @@
+package ui
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/deadlyedge/goDrawer/internal/settings"
+	"github.com/lxn/walk"
+)
+
+const settingsPollInterval = time.Second
+
+// startSettingsSync subscribes the UI to settings changes and begins
+// watching the settings file for edits made outside goDrawer. Reloads and
+// save conflicts are handled on the UI thread.
+func (a *App) startSettingsSync() {
+	a.unsubscribe = a.store.Subscribe(a.onConfigChanged)
+
+	onFileChanged := func() {
+		if a.mainWindow != nil {
+			a.mainWindow.Synchronize(a.onSettingsFileChanged)
+		}
+	}
+
+	a.store.HandleSaveErrors(func(err error) {
+		if errors.Is(err, settings.ErrConflict) {
+			onFileChanged()
+			return
+		}
+		log.Printf("failed to persist settings: %v", err)
+	})
+	a.store.Watch(settingsPollInterval, onFileChanged)
+}
+
+// stopSettingsSync writes pending changes and stops watching.
+func (a *App) stopSettingsSync() {
+	if a.unsubscribe != nil {
+		a.unsubscribe()
+		a.unsubscribe = nil
+	}
+	if err := a.store.Close(); err != nil {
+		log.Printf("failed to persist settings: %v", err)
+		if errors.Is(err, settings.ErrConflict) {
+			message := fmt.Sprintf("goDrawer could not save its latest changes to %s: %v.", a.store.Path(), err)
+			walk.MsgBox(nil, "Settings not saved", message, walk.MsgBoxOK|walk.MsgBoxIconWarning)
+		}
+	}
+}
+
+// onSettingsFileChanged reloads the settings file and applies it live. If
+// the UI holds changes that were never written, the user is asked which
+// version to keep.
+func (a *App) onSettingsFileChanged() {
+	if a.reloading {
+		return
+	}
+	a.reloading = true
+	defer func() { a.reloading = false }()
+
+	remote, report, err := settings.Reload(a.store.Path())
+	if err != nil {
+		log.Printf("ignoring unreadable settings file: %v", err)
+		return
+	}
+	settings.PrintReport(report)
+
+	if err := remote.Validate(); err != nil {
+		settings.PrintValidation(err, settings.Lenient)
+	}
+
+	current := a.store.Snapshot()
+	local := settings.Diff(a.store.Saved(), current)
+	if local != 0 && settings.Diff(current, remote) != 0 {
+		if !a.confirmDiscardLocalChanges(local) {
+			if err := a.store.Overwrite(); err != nil {
+				log.Printf("failed to persist settings: %v", err)
+			}
+			return
+		}
+	}
+
+	a.store.Replace("reload", remote)
+}
+
+func (a *App) confirmDiscardLocalChanges(local settings.Change) bool {
+	message := fmt.Sprintf(
+		"%s was changed outside goDrawer, but goDrawer also has changes to %s that were not saved yet.\n\n"+
+			"Yes: load the file and discard the goDrawer changes.\n"+
+			"No: keep the goDrawer changes and overwrite the file.",
+		a.store.Path(), local)
+
+	return walk.MsgBox(a.mainWindow, "Settings changed on disk", message, walk.MsgBoxYesNo|walk.MsgBoxIconWarning) == walk.DlgCmdYes
+}
+
+// onConfigChanged takes over the new settings and refreshes whatever the
+// changed sections affect. It runs on the goroutine that changed the store,
+// which for the UI is always the UI thread.
+func (a *App) onConfigChanged(event settings.Event) {
+	a.config = event.New
+	if event.Cause == "reload" {
+		log.Printf("settings reloaded: %s changed", event.Changes)
+	}
+
+	if event.Changes&(settings.ChangeDrawers|settings.ChangeProfiles) != 0 {
+		a.syncDrawerWindows(event.New)
+	}
+
+	a.updateTray()
+
+	if event.Changes.Has(settings.ChangeStartup) {
+		a.syncAutostart()
+	}
+
+	if event.Changes.Has(settings.ChangeControl) {
+		a.syncControl()
+	}
+
+	if event.Changes&(settings.ChangeGroups|settings.ChangeProfiles) != 0 || drawerListChanged(event.Old, event.New) {
+		a.scheduleRefresh()
+	}
+
+	if event.Old.ActiveTheme() != event.New.ActiveTheme() {
+		if err := a.updateTheme(event.New.ActiveTheme()); err != nil {
+			log.Printf("failed to apply theme: %v", err)
+		}
+	}
+}
+
+// drawerListChanged reports whether the drawers differ in anything the main
+// window or its drawer menus show. Sizes and view state only matter to
+// drawer windows.
+func drawerListChanged(old, next *settings.Settings) bool {
+	if len(old.Drawers) != len(next.Drawers) {
+		return true
+	}
+	for i := range old.Drawers {
+		a, b := old.Drawers[i], next.Drawers[i]
+		if a.ID != b.ID || a.Name != b.Name || a.Path != b.Path || a.Group != b.Group || a.Profile != b.Profile || a.Confined != b.Confined {
+			return true
+		}
+	}
+	return false
+}
+
+// syncDrawerWindows closes drawer windows whose drawer was removed, moved to
+// another folder or is not part of the active profile, and retitles the
+// rest.
+func (a *App) syncDrawerWindows(next *settings.Settings) {
+	for _, dw := range append([]*drawerWindow(nil), a.drawerWindows...) {
+		i := next.DrawerIndex(dw.drawer.ID)
+		if i < 0 || !next.DrawerVisible(next.Drawers[i]) || next.ResolvePath(next.Drawers[i].Path) != dw.root {
+			dw.close()
+			continue
+		}
+
+		if name := next.Drawers[i].Name; name != dw.drawer.Name {
+			dw.drawer.Name = name
+			if dw.window != nil {
+				dw.window.SetTitle(fmt.Sprintf("%s - goDrawer", name))
+			}
+			dw.updateHeader()
+		}
+		if confined := next.Drawers[i].Confined; confined != dw.drawer.Confined {
+			dw.drawer.Confined = confined
+			dw.applyConfinement()
+		}
+	}
+}
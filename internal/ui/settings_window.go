@@ -31,6 +31,10 @@ type settingsWindow struct {
 	autostartCheck *walk.CheckBox
 	lockCheck      *walk.CheckBox
 
+	profileCombo    *walk.ComboBox
+	profileChoices  []profileChoice
+	loadingProfiles bool
+
 	previewBrush *walk.SolidColorBrush
 }
 
@@ -42,19 +46,20 @@ func (a *App) openSettingsWindow() {
 }
 
 func (sw *settingsWindow) open() error {
-	theme := sw.app.config.Theme
+	theme := sw.app.config.ActiveTheme()
+	sw.profileChoices = sw.app.profileChoices()
 
 	dragHandler := func(x, y int, button walk.MouseButton) {
 		if button == walk.LeftButton && sw.window != nil {
-			beginWindowDrag(sw.window)
+			sw.app.beginWindowDrag(sw.window)
 		}
 	}
 
 	mwDef := declarative.MainWindow{
 		AssignTo:    &sw.window,
 		Title:       "Settings",
-		MinSize:     declarative.Size{Width: 300, Height: 400},
-		Size:        declarative.Size{Width: 300, Height: 400},
+		MinSize:     declarative.Size{Width: 300, Height: 460},
+		Size:        declarative.Size{Width: 300, Height: 460},
 		Layout:      declarative.VBox{MarginsZero: true, Spacing: 0},
 		OnMouseDown: dragHandler,
 		Children: []declarative.Widget{
@@ -80,6 +85,28 @@ func (sw *settingsWindow) open() error {
 					Spacing: 0,
 				},
 				Children: []declarative.Widget{
+					declarative.Composite{
+						Layout: declarative.VBox{Spacing: 4},
+						Children: []declarative.Widget{
+							declarative.Label{Text: "Profile"},
+							declarative.ComboBox{
+								AssignTo:              &sw.profileCombo,
+								Model:                 sw.profileChoices,
+								BindingMember:         "ID",
+								DisplayMember:         "Name",
+								CurrentIndex:          sw.activeProfileIndex(),
+								OnCurrentIndexChanged: sw.onProfileSelected,
+							},
+							declarative.Composite{
+								Layout: declarative.HBox{MarginsZero: true, Spacing: 4},
+								Children: []declarative.Widget{
+									declarative.PushButton{Text: "New...", OnClicked: sw.onNewProfile},
+									declarative.PushButton{Text: "Rename...", OnClicked: sw.onRenameProfile},
+									declarative.PushButton{Text: "Remove", OnClicked: sw.onRemoveProfile},
+								},
+							},
+						},
+					},
 					sw.sliderRow("Hue", 0, 360, theme.Hue, &sw.hueSlider, &sw.hueValue, dragHandler, sw.updatePreview),
 					sw.sliderRow("Saturation", 0, 100, theme.Saturation, &sw.satSlider, &sw.satValue, dragHandler, sw.updatePreview),
 					sw.sliderRow("Lightness", 0, 100, theme.Lightness, &sw.lightSlider, &sw.lightValue, dragHandler, sw.updatePreview),
@@ -148,10 +175,10 @@ func (sw *settingsWindow) open() error {
 		return err
 	}
 
-	// if err := makeWindowBorderless(sw.window); err != nil {
+	// if err := sw.app.platform.Windows.MakeBorderless(handle(sw.window)); err != nil {
 	// 	return err
 	// }
-	if err := hideFromTaskbar(sw.window); err != nil {
+	if err := sw.app.platform.Windows.HideFromTaskbar(handle(sw.window)); err != nil {
 		return err
 	}
 
@@ -259,17 +286,126 @@ func (sw *settingsWindow) applyAndClose() {
 		Alpha:      sw.alphaSlider.Value(),
 	}
 
-	sw.app.config.Theme = updatedTheme
-	sw.app.config.Startup.StartWithWindows = sw.autostartCheck.Checked()
-	sw.app.config.Startup.WindowLocked = sw.lockCheck.Checked()
+	profileID := sw.selectedProfileID()
+	startWithWindows := sw.autostartCheck.Checked()
+	windowLocked := sw.lockCheck.Checked()
 
-	if err := sw.app.updateTheme(updatedTheme); err != nil {
-		log.Printf("failed to apply theme: %v", err)
+	if err := sw.app.store.Update("settings", func(s *settings.Settings) error {
+		if err := s.SetActiveProfile(profileID); err != nil {
+			return err
+		}
+		s.SetActiveTheme(updatedTheme)
+		s.Startup.StartWithWindows = startWithWindows
+		s.Startup.WindowLocked = windowLocked
+		return nil
+	}); err != nil {
+		log.Printf("failed to apply settings: %v", err)
 	}
 
-	if err := settings.Update(sw.app.settingsPath, sw.app.config); err != nil {
-		log.Printf("failed to persist settings: %v", err)
+	sw.window.Close()
+}
+
+func (sw *settingsWindow) activeProfileIndex() int {
+	return sw.profileChoiceIndex(sw.app.config.ActiveProfileID())
+}
+
+// profileChoiceIndex returns the position of the profile with the given ID
+// in the picker, or that of the active profile when it is not there.
+func (sw *settingsWindow) profileChoiceIndex(id string) int {
+	for i, choice := range sw.profileChoices {
+		if choice.ID == id {
+			return i
+		}
 	}
+	active := sw.app.config.ActiveProfileID()
+	for i, choice := range sw.profileChoices {
+		if choice.ID == active {
+			return i
+		}
+	}
+	return 0
+}
 
-	sw.window.Close()
+func (sw *settingsWindow) selectedProfileID() string {
+	if sw.profileCombo == nil {
+		return ""
+	}
+	i := sw.profileCombo.CurrentIndex()
+	if i < 0 || i >= len(sw.profileChoices) {
+		return ""
+	}
+	return sw.profileChoices[i].ID
+}
+
+// onProfileSelected shows the chosen profile's theme on the sliders. The
+// app switches to the profile only when OK is clicked.
+func (sw *settingsWindow) onProfileSelected() {
+	if sw.loadingProfiles {
+		return
+	}
+
+	sw.loadTheme(sw.app.config.ProfileTheme(sw.selectedProfileID()))
+}
+
+func (sw *settingsWindow) onNewProfile() {
+	if id, ok := sw.app.onAddProfile(sw.window); ok {
+		sw.reloadProfiles(id)
+		sw.loadTheme(sw.app.config.ProfileTheme(id))
+	}
+}
+
+func (sw *settingsWindow) onRenameProfile() {
+	id := sw.selectedProfileID()
+	if id == "" {
+		showError(sw.window, "Rename profile", fmt.Errorf("the %s profile cannot be renamed", settings.BaseProfileName))
+		return
+	}
+	if sw.app.onRenameProfile(sw.window, id) {
+		sw.reloadProfiles(id)
+	}
+}
+
+func (sw *settingsWindow) onRemoveProfile() {
+	id := sw.selectedProfileID()
+	if id == "" {
+		showError(sw.window, "Remove profile", fmt.Errorf("the %s profile cannot be removed", settings.BaseProfileName))
+		return
+	}
+	if sw.app.onRemoveProfile(sw.window, id) {
+		sw.reloadProfiles(sw.app.config.ActiveProfileID())
+		sw.loadTheme(sw.app.config.ProfileTheme(sw.selectedProfileID()))
+	}
+}
+
+// reloadProfiles refills the profile picker after profiles were added,
+// renamed or removed, and selects the profile with the given ID, or the
+// active one when it is gone.
+func (sw *settingsWindow) reloadProfiles(id string) {
+	if sw.profileCombo == nil {
+		return
+	}
+
+	sw.loadingProfiles = true
+	defer func() { sw.loadingProfiles = false }()
+
+	sw.profileChoices = sw.app.profileChoices()
+	if err := sw.profileCombo.SetModel(sw.profileChoices); err != nil {
+		log.Printf("failed to reload profiles: %v", err)
+		return
+	}
+	if err := sw.profileCombo.SetCurrentIndex(sw.profileChoiceIndex(id)); err != nil {
+		log.Printf("failed to select profile: %v", err)
+	}
+}
+
+func (sw *settingsWindow) loadTheme(theme settings.Theme) {
+	if sw.hueSlider == nil {
+		return
+	}
+
+	sw.hueSlider.SetValue(theme.Hue)
+	sw.satSlider.SetValue(theme.Saturation)
+	sw.lightSlider.SetValue(theme.Lightness)
+	sw.alphaSlider.SetValue(theme.Alpha)
+	sw.updatePreview()
 }
@@ -0,0 +1,73 @@
+package ui
+
+import (
+	"log"
+
+	"github.com/deadlyedge/goDrawer/internal/assets"
+	"github.com/deadlyedge/goDrawer/internal/platform"
+	"github.com/lxn/walk"
+)
+
+func (a *App) setupTray() error {
+	tray, err := a.platform.Trays.NewTray(platform.TrayOptions{
+		Icon:    assets.Path(assets.AppIcon),
+		ToolTip: "goDrawer",
+		OnClick: a.toggleMainWindowVisibility,
+	})
+	if err != nil {
+		return err
+	}
+
+	a.tray = tray
+	a.updateTray()
+	return nil
+}
+
+func (a *App) closeTray() {
+	if a.tray != nil {
+		a.tray.Close()
+		a.tray = nil
+	}
+}
+
+// updateTray rebuilds the tray menu from the current state: whether the
+// main window is shown, the profiles and the changes Undo and Redo would
+// revert or repeat.
+func (a *App) updateTray() {
+	if a.tray == nil {
+		return
+	}
+
+	showHide := "Hide app"
+	if a.mainWindow == nil || !a.mainWindow.Visible() {
+		showHide = "Show app"
+	}
+
+	items := []platform.MenuItem{
+		{Text: "goDrawer", OnClick: a.showMainWindow},
+		{Text: showHide, OnClick: a.toggleMainWindowVisibility},
+		{Text: "Profile", Items: a.profileMenu()},
+		platform.Separator,
+		historyItem("Undo", a.undo, a.store.UndoCause),
+		historyItem("Redo", a.redo, a.store.RedoCause),
+		{Text: "Settings history...", OnClick: a.showHistory},
+		platform.Separator,
+		{Text: "Exit app", OnClick: func() { walk.App().Exit(0) }},
+	}
+
+	if err := a.tray.SetMenu(items); err != nil {
+		log.Printf("failed to update tray menu: %v", err)
+	}
+}
+
+// historyItem names the change an Undo or Redo entry would revert or
+// repeat, and disables it when there is none.
+func historyItem(verb string, onClick func(), cause func() (string, bool)) platform.MenuItem {
+	text, ok := cause()
+	if ok {
+		text = verb + " " + text
+	} else {
+		text = verb
+	}
+	return platform.MenuItem{Text: text, Disabled: !ok, OnClick: onClick}
+}
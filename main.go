@@ -1,21 +1,131 @@
 package main
 
 import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
 	"log"
+	"os"
 
+	"github.com/deadlyedge/goDrawer/internal/cli"
+	"github.com/deadlyedge/goDrawer/internal/instance"
 	"github.com/deadlyedge/goDrawer/internal/settings"
 	"github.com/deadlyedge/goDrawer/internal/ui"
 )
 
+// launchFlags are the flags of a goDrawer launch. A launch while goDrawer
+// is already running forwards them to the running instance.
+type launchFlags struct {
+	config  string
+	strict  bool
+	request ui.Request
+}
+
+func newFlagSet(errorHandling flag.ErrorHandling) (*flag.FlagSet, *launchFlags) {
+	fs := flag.NewFlagSet("goDrawer", errorHandling)
+	lf := &launchFlags{}
+	fs.StringVar(&lf.config, "config", "", "path to the settings file (overrides "+settings.EnvConfigPath+")")
+	fs.BoolVar(&lf.strict, "strict", false, "refuse to start when the settings file has problems")
+	fs.StringVar(&lf.request.OpenDrawer, "open-drawer", "", "open the drawer with this ID or name")
+	fs.BoolVar(&lf.request.Show, "show", false, "show the main window")
+	fs.BoolVar(&lf.request.Reload, "reload", false, "reload the settings file")
+	return fs, lf
+}
+
 func main() {
-	const configPath = "goDrawer-settings.toml"
+	fs, flags := newFlagSet(flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: goDrawer [FLAGS] [COMMAND]")
+		fs.PrintDefaults()
+		fmt.Fprintln(fs.Output())
+		cli.Usage(fs.Output())
+	}
+	fs.Parse(os.Args[1:])
+
+	location, err := settings.Locate(flags.config)
+	if err != nil {
+		log.Fatalf("unable to locate settings: %v", err)
+	}
+	configPath := location.Path
+
+	if fs.NArg() > 0 {
+		os.Exit(cli.Run(configPath, fs.Args(), os.Stdout, os.Stderr))
+	}
+
+	lockPath := instance.LockPath(configPath)
+	server, err := instance.Claim(lockPath)
+	if errors.Is(err, instance.ErrRunning) {
+		if err := instance.Forward(lockPath, os.Args[1:]); err != nil {
+			log.Fatalf("goDrawer is already running but could not be reached: %v", err)
+		}
+		return
+	}
+	if err != nil {
+		log.Printf("warn: running without single-instance check: %v", err)
+	}
 
-	setting, err := settings.Read(configPath)
+	fmt.Printf("Settings file: %s\n\n", location)
+
+	mode := settings.Lenient
+	if flags.strict {
+		mode = settings.Strict
+	}
+
+	setting, report, err := settings.Read(configPath)
 	if err != nil {
 		log.Fatalf("unable to read settings: %v", err)
 	}
 
+	settings.PrintReport(report)
 	settings.Print(setting)
 
-	ui.MainWindow(setting, configPath)
+	if mode == settings.Strict && len(report.UnknownKeys) > 0 {
+		log.Fatalf("unable to start: settings file has %d unknown keys", len(report.UnknownKeys))
+	}
+
+	if err := setting.Validate(); err != nil {
+		settings.PrintValidation(err, mode)
+		if mode == settings.Strict {
+			log.Fatalf("unable to start: %v", err)
+		}
+	}
+
+	opts := ui.Options{Startup: flags.request}
+	if server != nil {
+		// Launches queued during startup are delivered before the UI reads
+		// the channel, so a full channel drops rather than blocks.
+		requests := make(chan ui.Request, 8)
+		server.Serve(func(args []string) {
+			request, ok := forwardedRequest(args)
+			if !ok {
+				return
+			}
+			select {
+			case requests <- request:
+			default:
+				log.Printf("warn: dropping forwarded arguments %q: too many pending", args)
+			}
+		})
+		defer server.Close()
+		opts.Requests = requests
+	}
+
+	ui.MainWindow(setting, configPath, opts)
+}
+
+// forwardedRequest parses the command line of a later launch. A launch
+// without anything to do brings the main window up.
+func forwardedRequest(args []string) (ui.Request, bool) {
+	fs, flags := newFlagSet(flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	if err := fs.Parse(args); err != nil {
+		log.Printf("ignoring forwarded arguments %q: %v", args, err)
+		return ui.Request{}, false
+	}
+
+	if flags.request == (ui.Request{}) {
+		flags.request.Show = true
+	}
+	return flags.request, true
 }